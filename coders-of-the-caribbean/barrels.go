@@ -0,0 +1,68 @@
+package main
+
+import "sort"
+
+// ETA estimates the number of rounds the ship needs to reach pos, sailing
+// at full speed once it got going.
+func ETA(s *Ship, pos Offset) int {
+	if s.At(pos) {
+		return 0
+	}
+	eta := (s.Bow().Dist(pos) + MaxShipSpeed - 1) / MaxShipSpeed
+	if s.Speed == 0 {
+		eta++
+	}
+	return eta
+}
+
+// AssignBarrels picks a barrel for each of our ships, no two ships going
+// for the same one. Pairs are taken greedily from the shortest ETA,
+// barrels an enemy ship reaches first coming last.
+func (g *Game) AssignBarrels() map[int]*Barrel {
+	type pair struct {
+		ship   *Ship
+		barrel *Barrel
+		eta    int
+		lost   bool // an enemy ship gets there first
+	}
+	var pairs []pair
+	for _, b := range g.Barrels {
+		enemy := -1
+		for _, s := range g.Ships {
+			if s.F == EnnemyFaction && (enemy < 0 || ETA(s, b.Offset) < enemy) {
+				enemy = ETA(s, b.Offset)
+			}
+		}
+		for _, s := range g.Ships {
+			if s.F != PlayerFaction {
+				continue
+			}
+			eta := ETA(s, b.Offset)
+			pairs = append(pairs, pair{
+				ship:   s,
+				barrel: b,
+				eta:    eta,
+				lost:   enemy >= 0 && enemy < eta,
+			})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].lost != pairs[j].lost {
+			return !pairs[i].lost
+		}
+		if pairs[i].eta != pairs[j].eta {
+			return pairs[i].eta < pairs[j].eta
+		}
+		return pairs[i].barrel.Rhum > pairs[j].barrel.Rhum
+	})
+	assigned := make(map[int]*Barrel)
+	taken := make(map[int]bool)
+	for _, p := range pairs {
+		if assigned[p.ship.id] != nil || taken[p.barrel.id] {
+			continue
+		}
+		assigned[p.ship.id] = p.barrel
+		taken[p.barrel.id] = true
+	}
+	return assigned
+}
@@ -0,0 +1,82 @@
+package main
+
+import "fmt"
+
+const (
+	MineCooldown    = 4 // rounds to wait after laying a mine
+	MineDistanceMax = 6 // farthest enemy worth laying a mine for
+	KiteRadius      = 4 // distance of the cells to flee to
+	PursueLookahead = 2 // rounds ahead of the enemy to aim for
+)
+
+// Leading reports whether our ships hold more rum than the enemy ones.
+func (g *Game) Leading() bool {
+	rhum := 0
+	for _, s := range g.Ships {
+		if s.F == PlayerFaction {
+			rhum += s.Rhum
+		} else {
+			rhum -= s.Rhum
+		}
+	}
+	return rhum > 0
+}
+
+// Endgame returns the action of a ship once no barrel is left for it. When
+// leading, the ship runs away from the enemy and mines its wake, otherwise
+// it hunts the closest enemy and fires at it.
+func (g *Game) Endgame(s *Ship) string {
+	enemy := g.ClosestEnemy(s)
+	if enemy == nil {
+		return "WAIT\n"
+	}
+	if g.Leading() {
+		return g.kite(s, enemy)
+	}
+	return g.pursue(s, enemy)
+}
+
+// CanMine reports whether the ship can lay a mine this round.
+func (g *Game) CanMine(s *Ship) bool {
+	last, ok := g.Mined[s.id]
+	return !ok || g.Round-last > MineCooldown
+}
+
+// kite lays a mine when the enemy follows the ship, or sails to the cell
+// of the surrounding ring farthest from the enemy, keeping off the edges.
+func (g *Game) kite(s *Ship, enemy *Ship) string {
+	stern := s.Stern()
+	behind := enemy.Dist(stern) < enemy.Dist(s.Bow()) && enemy.Dist(stern) <= MineDistanceMax
+	if behind && g.CanMine(s) && g.Safe(s, "MINE") {
+		g.Mined[s.id] = g.Round
+		return "MINE\n"
+	}
+	var target Offset
+	best := -1
+	for _, c := range s.Ring(KiteRadius) {
+		if !c.Inside() {
+			continue
+		}
+		score := 2*enemy.Dist(c) + min(c.X, MapWidth-1-c.X, c.Y, MapHeight-1-c.Y, 3)
+		if score > best {
+			target, best = c, score
+		}
+	}
+	if best < 0 {
+		return "WAIT\n"
+	}
+	if a := g.Navigate(s, target); a != "" {
+		return a + "\n"
+	}
+	return fmt.Sprintf("MOVE %d %d\n", target.X, target.Y)
+}
+
+// pursue sails to where the enemy will be in a few rounds, the cannon
+// being fired as soon as a shot is likely to hit.
+func (g *Game) pursue(s *Ship, enemy *Ship) string {
+	target := enemy.Ahead(PursueLookahead)
+	if a := g.Navigate(s, target); a != "" {
+		return a + "\n"
+	}
+	return fmt.Sprintf("MOVE %d %d\n", target.X, target.Y)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	FireDistanceMax = 10
+	CannonCooldown  = 1 // rounds to wait after firing
+)
+
+// TravelTime returns the number of rounds a cannonball fired from bow
+// travels before landing on target.
+func TravelTime(bow, target Offset) int {
+	return 1 + int(math.Round(float64(bow.Dist(target))/3))
+}
+
+// Ahead returns the position of the ship center in the given number of
+// rounds if it keeps going straight at its speed.
+func (s *Ship) Ahead(rounds int) Offset {
+	pos := s.Offset
+	for i := 0; i < rounds*s.Speed; i++ {
+		next := pos.Neighbor(s.Rotation)
+		if !next.Inside() {
+			break
+		}
+		pos = next
+	}
+	return pos
+}
+
+// Aim returns the cell where a cannonball fired by s this round meets
+// target. The cannonball lands at the end of the round matching its travel
+// time, the target moves every round until then, this one included. The
+// center is preferred, hitting the bow or the stern still deals damage.
+// Cells where s itself will be are skipped.
+func (s *Ship) Aim(target *Ship) (Offset, bool) {
+	bow := s.Bow()
+	for rounds := 1; rounds <= FireDistanceMax; rounds++ {
+		center := target.Ahead(rounds + 1)
+		cells := []Offset{
+			center,
+			center.Neighbor(target.Rotation),
+			center.Neighbor((target.Rotation + 3) % 6),
+		}
+		own := *s
+		own.Offset = s.Ahead(rounds + 1)
+		for _, pos := range cells {
+			if !pos.Inside() || bow.Dist(pos) > FireDistanceMax || own.At(pos) {
+				continue
+			}
+			if TravelTime(bow, pos) == rounds {
+				return pos, true
+			}
+		}
+	}
+	return Offset{}, false
+}
+
+// ClosestEnemy returns the enemy ship closest to s, or nil.
+func (g *Game) ClosestEnemy(s *Ship) *Ship {
+	var target *Ship
+	for _, e := range g.Ships {
+		if e.F == EnnemyFaction && (target == nil || s.Dist(e.Offset) < s.Dist(target.Offset)) {
+			target = e
+		}
+	}
+	return target
+}
+
+// CanFire reports whether the cannon of the ship is ready this round.
+func (g *Game) CanFire(s *Ship) bool {
+	last, ok := g.Fired[s.id]
+	return !ok || g.Round-last > CannonCooldown
+}
+
+// Fire returns a FIRE action on the closest enemy ship the cannon of s is
+// likely to hit, or an empty string.
+func (g *Game) Fire(s *Ship) string {
+	if !g.CanFire(s) {
+		return ""
+	}
+	target := g.ClosestEnemy(s)
+	if target == nil {
+		return ""
+	}
+	pos, ok := s.Aim(target)
+	if !ok {
+		return ""
+	}
+	g.Fired[s.id] = g.Round
+	return fmt.Sprintf("FIRE %d %d\n", pos.X, pos.Y)
+}
@@ -0,0 +1,145 @@
+package main
+
+import "math"
+
+const (
+	MapWidth  = 23
+	MapHeight = 21
+)
+
+// Offsets of the six neighbours of a cell, for even and odd rows. Direction
+// 0 is east, then counterclockwise.
+var directions = [2][6][2]int{
+	{{1, 0}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}},
+	{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {0, 1}, {1, 1}},
+}
+
+// Offset holds the odd-row offset coordinates used by the referee: odd rows
+// are shifted half a cell to the right.
+type Offset struct {
+	X, Y int
+}
+
+// Cube holds cube coordinates, X + Y + Z is always 0.
+type Cube struct {
+	X, Y, Z int
+}
+
+func (a Cube) Dist(b Cube) int {
+	return (abs(a.X-b.X) + abs(a.Y-b.Y) + abs(a.Z-b.Z)) / 2
+}
+
+// Offset converts cube coordinates back to offset coordinates.
+func (a Cube) Offset() Offset {
+	return Offset{X: a.X + (a.Z-(a.Z&1))/2, Y: a.Z}
+}
+
+// Cube converts the offset coordinates of a to cube coordinates.
+func (a Offset) Cube() Cube {
+	x := a.X - (a.Y-(a.Y&1))/2
+	z := a.Y
+	return Cube{
+		X: x,
+		Y: -x - z,
+		Z: z,
+	}
+}
+
+func (a Offset) Dist(b Offset) int {
+	return a.Cube().Dist(b.Cube())
+}
+
+// Neighbor returns the cell next to a in direction dir.
+func (a Offset) Neighbor(dir int) Offset {
+	d := directions[a.Y&1][dir]
+	return Offset{X: a.X + d[0], Y: a.Y + d[1]}
+}
+
+// Inside reports whether a lies on the map.
+func (a Offset) Inside() bool {
+	return a.X >= 0 && a.X < MapWidth && a.Y >= 0 && a.Y < MapHeight
+}
+
+// Line returns the cells crossed by the straight line going from a to b,
+// both included.
+func (a Offset) Line(b Offset) []Offset {
+	n := a.Dist(b)
+	ca, cb := a.Cube(), b.Cube()
+	line := make([]Offset, 0, n+1)
+	for i := 0; i <= n; i++ {
+		t := 0.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		// Nudge the line so that points on a cell edge always round the
+		// same way.
+		line = append(line, cubeRound(
+			lerp(ca.X, cb.X, t)+1e-6,
+			lerp(ca.Y, cb.Y, t)+1e-6,
+			lerp(ca.Z, cb.Z, t)-2e-6,
+		).Offset())
+	}
+	return line
+}
+
+// Ring returns the cells at distance radius of a, going counterclockwise.
+// Cells out of the map are included.
+func (a Offset) Ring(radius int) []Offset {
+	if radius == 0 {
+		return []Offset{a}
+	}
+	ring := make([]Offset, 0, 6*radius)
+	pos := a
+	for i := 0; i < radius; i++ {
+		pos = pos.Neighbor(4)
+	}
+	for dir := 0; dir < 6; dir++ {
+		for i := 0; i < radius; i++ {
+			ring = append(ring, pos)
+			pos = pos.Neighbor(dir)
+		}
+	}
+	return ring
+}
+
+// Spiral returns the cells at distance at most radius of a, from the
+// closest to the farthest.
+func (a Offset) Spiral(radius int) []Offset {
+	var spiral []Offset
+	for r := 0; r <= radius; r++ {
+		spiral = append(spiral, a.Ring(r)...)
+	}
+	return spiral
+}
+
+func lerp(a, b int, t float64) float64 {
+	return float64(a) + float64(b-a)*t
+}
+
+// cubeRound returns the cell holding the fractional cube coordinates.
+func cubeRound(x, y, z float64) Cube {
+	rx, ry, rz := math.Round(x), math.Round(y), math.Round(z)
+	dx, dy, dz := math.Abs(rx-x), math.Abs(ry-y), math.Abs(rz-z)
+	switch {
+	case dx > dy && dx > dz:
+		rx = -ry - rz
+	case dy > dz:
+		ry = -rx - rz
+	default:
+		rz = -rx - ry
+	}
+	return Cube{X: int(rx), Y: int(ry), Z: int(rz)}
+}
+
+func abs(x int) int {
+	// TODO: once golang.org/issue/13095 is fixed, change this to:
+	// return Float64frombits(Float64bits(x) &^ (1 << 63))
+	// But for now, this generates better code and can also be inlined:
+	if x < 0 {
+		return -x
+	}
+	if x == 0 {
+		return 0 // return correctly abs(-0)
+	}
+	return x
+}
@@ -0,0 +1,193 @@
+package main
+
+import "testing"
+
+func pos(x, y int) Offset { return Offset{X: x, Y: y} }
+
+func TestCubeRoundTrip(t *testing.T) {
+	for y := -2; y < MapHeight+2; y++ {
+		for x := -2; x < MapWidth+2; x++ {
+			a := pos(x, y)
+			c := a.Cube()
+			if c.X+c.Y+c.Z != 0 {
+				t.Errorf("Cube(%d, %d) = %v, coordinates do not sum to 0", x, y, c)
+			}
+			if got := c.Offset(); got != a {
+				t.Errorf("Offset(Cube(%d, %d)) = %d %d", x, y, got.X, got.Y)
+			}
+		}
+	}
+}
+
+func TestNeighbor(t *testing.T) {
+	tests := []struct {
+		from Offset
+		want [6]Offset
+	}{
+		// Even row.
+		{pos(5, 4), [6]Offset{pos(6, 4), pos(5, 3), pos(4, 3), pos(4, 4), pos(4, 5), pos(5, 5)}},
+		// Odd row.
+		{pos(5, 5), [6]Offset{pos(6, 5), pos(6, 4), pos(5, 4), pos(4, 5), pos(5, 6), pos(6, 6)}},
+	}
+	for _, tt := range tests {
+		for dir, want := range tt.want {
+			got := tt.from.Neighbor(dir)
+			if got != want {
+				t.Errorf("(%d, %d).Neighbor(%d) = %d %d, want %d %d",
+					tt.from.X, tt.from.Y, dir, got.X, got.Y, want.X, want.Y)
+			}
+			if d := tt.from.Dist(got); d != 1 {
+				t.Errorf("(%d, %d).Neighbor(%d) at distance %d", tt.from.X, tt.from.Y, dir, d)
+			}
+			if back := got.Neighbor((dir + 3) % 6); back != tt.from {
+				t.Errorf("opposite of (%d, %d).Neighbor(%d) = %d %d", tt.from.X, tt.from.Y, dir, back.X, back.Y)
+			}
+		}
+	}
+}
+
+func TestDist(t *testing.T) {
+	tests := []struct {
+		a, b Offset
+		want int
+	}{
+		{pos(0, 0), pos(0, 0), 0},
+		{pos(0, 0), pos(5, 0), 5},
+		{pos(0, 0), pos(0, 4), 4},
+		{pos(0, 0), pos(3, 6), 6},
+		{pos(2, 1), pos(0, 5), 4},
+		{pos(0, 0), pos(MapWidth-1, MapHeight-1), 32},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Dist(tt.b); got != tt.want {
+			t.Errorf("(%d, %d).Dist(%d, %d) = %d, want %d", tt.a.X, tt.a.Y, tt.b.X, tt.b.Y, got, tt.want)
+		}
+		if got := tt.b.Dist(tt.a); got != tt.want {
+			t.Errorf("(%d, %d).Dist(%d, %d) = %d, want %d", tt.b.X, tt.b.Y, tt.a.X, tt.a.Y, got, tt.want)
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	ends := []Offset{pos(0, 0), pos(5, 0), pos(3, 7), pos(11, 10), pos(22, 20), pos(0, 20), pos(7, 3)}
+	for _, a := range ends {
+		for _, b := range ends {
+			line := a.Line(b)
+			if len(line) != a.Dist(b)+1 {
+				t.Errorf("(%d, %d).Line(%d, %d) has %d cells, want %d", a.X, a.Y, b.X, b.Y, len(line), a.Dist(b)+1)
+				continue
+			}
+			if line[0] != a || line[len(line)-1] != b {
+				t.Errorf("(%d, %d).Line(%d, %d) goes from %v to %v", a.X, a.Y, b.X, b.Y, line[0], line[len(line)-1])
+			}
+			for i := 1; i < len(line); i++ {
+				if d := line[i-1].Dist(line[i]); d != 1 {
+					t.Errorf("(%d, %d).Line(%d, %d) jumps %d cells at %d", a.X, a.Y, b.X, b.Y, d, i)
+				}
+			}
+		}
+	}
+	line := pos(2, 4).Line(pos(6, 4))
+	for i, c := range line {
+		if want := pos(2+i, 4); c != want {
+			t.Errorf("straight line cell %d = %d %d, want %d %d", i, c.X, c.Y, want.X, want.Y)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	for _, center := range []Offset{pos(10, 10), pos(3, 7), pos(0, 0)} {
+		for radius := 0; radius <= 4; radius++ {
+			ring := center.Ring(radius)
+			want := 6 * radius
+			if radius == 0 {
+				want = 1
+			}
+			if len(ring) != want {
+				t.Errorf("(%d, %d).Ring(%d) has %d cells, want %d", center.X, center.Y, radius, len(ring), want)
+			}
+			seen := make(map[Offset]bool)
+			for i, c := range ring {
+				if d := center.Dist(c); d != radius {
+					t.Errorf("(%d, %d).Ring(%d) holds %d %d at distance %d", center.X, center.Y, radius, c.X, c.Y, d)
+				}
+				if seen[c] {
+					t.Errorf("(%d, %d).Ring(%d) holds %d %d twice", center.X, center.Y, radius, c.X, c.Y)
+				}
+				seen[c] = true
+				if next := ring[(i+1)%len(ring)]; radius > 0 && c.Dist(next) != 1 {
+					t.Errorf("(%d, %d).Ring(%d) is not contiguous at %d", center.X, center.Y, radius, i)
+				}
+			}
+		}
+	}
+}
+
+func TestSpiral(t *testing.T) {
+	center := pos(8, 9)
+	spiral := center.Spiral(3)
+	if want := 1 + 6 + 12 + 18; len(spiral) != want {
+		t.Fatalf("Spiral(3) has %d cells, want %d", len(spiral), want)
+	}
+	if spiral[0] != center {
+		t.Errorf("Spiral(3) starts at %d %d, want the center", spiral[0].X, spiral[0].Y)
+	}
+	seen := make(map[Offset]bool)
+	prev := 0
+	for _, c := range spiral {
+		d := center.Dist(c)
+		if d < prev || d > 3 {
+			t.Errorf("Spiral(3) holds %d %d at distance %d after distance %d", c.X, c.Y, d, prev)
+		}
+		prev = d
+		if seen[c] {
+			t.Errorf("Spiral(3) holds %d %d twice", c.X, c.Y)
+		}
+		seen[c] = true
+	}
+}
+
+func TestInside(t *testing.T) {
+	tests := []struct {
+		x, y int
+		want bool
+	}{
+		{0, 0, true},
+		{MapWidth - 1, 0, true},
+		{0, MapHeight - 1, true},
+		{MapWidth - 1, MapHeight - 1, true},
+		{11, 10, true},
+		{-1, 0, false},
+		{0, -1, false},
+		{MapWidth, 0, false},
+		{0, MapHeight, false},
+		{MapWidth, MapHeight, false},
+	}
+	for _, tt := range tests {
+		if got := pos(tt.x, tt.y).Inside(); got != tt.want {
+			t.Errorf("(%d, %d).Inside() = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+	// Rows are shifted right on odd rows, the map has 21 rows so the top and
+	// bottom rows are both even.
+	corners := []struct {
+		from   Offset
+		inside int
+	}{
+		{pos(0, 0), 2},
+		{pos(MapWidth-1, 0), 3},
+		{pos(0, MapHeight-1), 2},
+		{pos(MapWidth-1, MapHeight-1), 3},
+	}
+	for _, c := range corners {
+		n := 0
+		for dir := 0; dir < 6; dir++ {
+			if c.from.Neighbor(dir).Inside() {
+				n++
+			}
+		}
+		if n != c.inside {
+			t.Errorf("(%d, %d) has %d neighbours inside, want %d", c.from.X, c.from.Y, n, c.inside)
+		}
+	}
+}
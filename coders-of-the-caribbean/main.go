@@ -2,9 +2,8 @@ package main
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
-	"time"
+	"strings"
 )
 
 const (
@@ -26,17 +25,9 @@ func (f Faction) String() string {
 	return str
 }
 
-type Vec3 struct {
-	x, y, z int
-}
-
-func (a Vec3) Dist(b Vec3) int {
-	return (abs(a.x-b.x) + abs(a.y-b.y) + abs(a.z-b.z)) / 2
-}
-
 type GameObject struct {
-	id   int
-	x, y int
+	id int
+	Offset
 }
 
 func (o GameObject) String() string {
@@ -45,20 +36,6 @@ func (o GameObject) String() string {
 
 func (a GameObject) ID() int { return a.id }
 
-func (a GameObject) CubeCoord() Vec3 {
-	x := a.x - (a.y-(a.y&1))/2
-	z := a.y
-	return Vec3{
-		x: x,
-		z: z,
-		y: -x - z,
-	}
-}
-
-func (a GameObject) Dist(b GameObject) int {
-	return a.CubeCoord().Dist(b.CubeCoord())
-}
-
 type Barrel struct {
 	GameObject
 	Rhum int
@@ -72,16 +49,74 @@ type Ship struct {
 	Rotation int
 }
 
+func (s *Ship) Bow() Offset   { return s.Neighbor(s.Rotation) }
+func (s *Ship) Stern() Offset { return s.Neighbor((s.Rotation + 3) % 6) }
+
+// Cells returns the bow, center and stern of the ship.
+func (s *Ship) Cells() [3]Offset {
+	return [3]Offset{s.Bow(), s.Offset, s.Stern()}
+}
+
+// At reports whether pos is one of the cells of the ship.
+func (s *Ship) At(pos Offset) bool {
+	for _, c := range s.Cells() {
+		if c == pos {
+			return true
+		}
+	}
+	return false
+}
+
+type Mine struct {
+	GameObject
+}
+
+type Cannonball struct {
+	GameObject
+	Ship   int // ship which fired
+	Impact int // round at the end of which the cannonball lands
+}
+
 type Game struct {
-	Objects map[int]Object
-	Round   int
-	Ships   []*Ship
-	Barrels []*Barrel
+	Objects     map[int]Object
+	Round       int
+	Ships       []*Ship
+	Barrels     []*Barrel
+	Mines       []*Mine
+	Cannonballs []*Cannonball
+	Fired       map[int]int // last round each of our ships fired
+	Mined       map[int]int // last round each of our ships laid a mine
+}
+
+// Safe reports whether the ship takes no damage this round with the
+// action, the other ships keeping their course.
+func (g *Game) Safe(s *Ship, action string) bool {
+	sim := NewSimulation(g)
+	sim.Step(map[int]string{s.id: action})
+	return sim.Damage[s.id] == 0
+}
+
+// avoid replaces the action with a low level one when it makes the ship hit
+// a mine or a cannonball this round. MOVE is checked as WAIT, the path the
+// referee picks being unknown.
+func (g *Game) avoid(s *Ship, action string) string {
+	if g.Safe(s, strings.TrimSpace(action)) {
+		return action
+	}
+	for _, a := range lowLevelActions {
+		if g.Safe(s, a) {
+			fmt.Fprintln(os.Stderr, s, "avoids hazard:", a)
+			return a + "\n"
+		}
+	}
+	return action
 }
 
 func main() {
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
-	game := &Game{}
+	game := &Game{
+		Fired: make(map[int]int),
+		Mined: make(map[int]int),
+	}
 	for {
 		// myShipCount: the number of remaining ships
 		var myShipCount int
@@ -93,6 +128,8 @@ func main() {
 		fmt.Scan(&entityCount)
 		game.Objects = make(map[int]Object)
 		game.Barrels = nil
+		game.Mines = nil
+		game.Cannonballs = nil
 
 		for i := 0; i < entityCount; i++ {
 			var entityId int
@@ -100,9 +137,8 @@ func main() {
 			var x, y, arg1, arg2, arg3, arg4 int
 			fmt.Scan(&entityId, &entityType, &x, &y, &arg1, &arg2, &arg3, &arg4)
 			o := GameObject{
-				id: entityId,
-				x:  x,
-				y:  y,
+				id:     entityId,
+				Offset: Offset{X: x, Y: y},
 			}
 			switch entityType {
 			case "SHIP":
@@ -122,59 +158,49 @@ func main() {
 				}
 				game.Barrels = append(game.Barrels, b)
 				game.Objects[b.id] = b
+			case "MINE":
+				m := &Mine{GameObject: o}
+				game.Mines = append(game.Mines, m)
+				game.Objects[m.id] = m
+			case "CANNONBALL":
+				c := &Cannonball{
+					GameObject: o,
+					Ship:       arg1,
+					Impact:     game.Round + arg2 - 1,
+				}
+				game.Cannonballs = append(game.Cannonballs, c)
+				game.Objects[c.id] = c
 			default:
 				fmt.Fprintln(os.Stderr, "unknown entity:", entityType)
 				continue
 			}
 		}
+		barrels := game.AssignBarrels()
 		for _, s := range game.Ships {
 			if s.F == EnnemyFaction {
 				continue
 			}
 
-			// Find closest barrel.
-			barrels := barrelToGOSlice(game.Barrels)
-			sortGOSlice(barrels, func(i, j int) bool { return barrels[i].Dist(s.GameObject) < barrels[j].Dist(s.GameObject) })
-
-			action := "WAIT"
-			if len(barrels) > 0 {
-				action = fmt.Sprintf("MOVE %d %d\n", barrels[0].x, barrels[0].y)
-			} else if game.Round%5 == 0 {
-				action = fmt.Sprintf("MOVE %d %d\n", random.Intn(22), random.Intn(23))
+			// Shoot rather than steer only when no barrel is close, firing
+			// keeps the ship going straight.
+			barrel := barrels[s.id]
+			action := ""
+			if (barrel == nil || barrel.Dist(s.Offset) > FireDistanceMax/2) && game.Safe(s, "WAIT") {
+				action = game.Fire(s)
+			}
+			switch {
+			case action != "":
+			case barrel != nil:
+				action = fmt.Sprintf("MOVE %d %d\n", barrel.X, barrel.Y)
+				if a := game.Navigate(s, barrel.Offset); a != "" {
+					action = a + "\n"
+				}
+			default:
+				action = game.Endgame(s)
 			}
+			action = game.avoid(s, action)
 			os.Stdout.Write([]byte(action))
 		}
 		game.Round++
 	}
 }
-
-func abs(x int) int {
-	// TODO: once golang.org/issue/13095 is fixed, change this to:
-	// return Float64frombits(Float64bits(x) &^ (1 << 63))
-	// But for now, this generates better code and can also be inlined:
-	if x < 0 {
-		return -x
-	}
-	if x == 0 {
-		return 0 // return correctly abs(-0)
-	}
-	return x
-}
-
-func sortGOSlice(objects []*GameObject, less func(i, j int) bool) {
-	for i := range objects {
-		for j := range objects[i:] {
-			if less(i, j) {
-				objects[i], objects[j] = objects[j], objects[i]
-			}
-		}
-	}
-}
-
-func barrelToGOSlice(barrels []*Barrel) []*GameObject {
-	objects := make([]*GameObject, len(barrels))
-	for i, b := range barrels {
-		objects[i] = &b.GameObject
-	}
-	return objects
-}
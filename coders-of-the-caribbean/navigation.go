@@ -0,0 +1,160 @@
+package main
+
+const (
+	NavigationDepth = 20 // rounds explored by Navigate
+	ShipsLookahead  = 2  // rounds during which the other ships block cells
+)
+
+var lowLevelActions = []string{"WAIT", "FASTER", "SLOWER", "PORT", "STARBOARD"}
+
+// hazards holds the cells a ship must not enter, indexed by round for the
+// cannonballs and the other ships.
+type hazards struct {
+	round       int
+	mines       [MapWidth][MapHeight]bool
+	cannonballs map[int][]Offset
+	ships       []Offset
+}
+
+func (g *Game) hazards(s *Ship) *hazards {
+	h := &hazards{
+		round:       g.Round,
+		cannonballs: make(map[int][]Offset),
+	}
+	for _, m := range g.Mines {
+		h.mines[m.X][m.Y] = true
+	}
+	for _, c := range g.Cannonballs {
+		h.cannonballs[c.Impact] = append(h.cannonballs[c.Impact], c.Offset)
+	}
+	for _, o := range g.Ships {
+		if o.id != s.id {
+			cells := o.Cells()
+			h.ships = append(h.ships, cells[:]...)
+		}
+	}
+	return h
+}
+
+// mine reports whether one of the ship cells lies on a mine.
+func (h *hazards) mine(s *Ship) bool {
+	for _, c := range s.Cells() {
+		if c.Inside() && h.mines[c.X][c.Y] {
+			return true
+		}
+	}
+	return false
+}
+
+// ship reports whether pos lies on another ship during the given round.
+func (h *hazards) ship(round int, pos Offset) bool {
+	if round >= h.round+ShipsLookahead {
+		return false
+	}
+	for _, c := range h.ships {
+		if c == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// cannonball reports whether a cannonball lands on the ship at the end of
+// the given round.
+func (h *hazards) cannonball(round int, s *Ship) bool {
+	for _, c := range h.cannonballs[round] {
+		if s.At(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// sail returns the ship at the end of the round with the low level action,
+// whether it went through target and whether it stayed clear of the
+// hazards. Running into another ship counts as a hazard.
+func (h *hazards) sail(s Ship, action string, round int, target Offset) (Ship, bool, bool) {
+	switch action {
+	case "FASTER":
+		if s.Speed < MaxShipSpeed {
+			s.Speed++
+		}
+	case "SLOWER":
+		if s.Speed > 0 {
+			s.Speed--
+		}
+	}
+	reached := false
+	for i := 0; i < s.Speed; i++ {
+		pos := s.Bow()
+		if !pos.Inside() {
+			s.Speed = 0
+			break
+		}
+		if h.ship(round, pos.Neighbor(s.Rotation)) {
+			return s, false, false
+		}
+		s.Offset = pos
+		if h.mine(&s) {
+			return s, false, false
+		}
+		reached = reached || s.At(target)
+	}
+	switch action {
+	case "PORT":
+		s.Rotation = (s.Rotation + 1) % 6
+	case "STARBOARD":
+		s.Rotation = (s.Rotation + 5) % 6
+	}
+	if action == "PORT" || action == "STARBOARD" {
+		if h.ship(round, s.Bow()) || h.ship(round, s.Stern()) || h.mine(&s) {
+			return s, false, false
+		}
+	}
+	if h.cannonball(round, &s) {
+		return s, false, false
+	}
+	return s, reached || s.At(target), true
+}
+
+// Navigate searches the ship positions, rotations and speeds reachable in
+// the next rounds and returns the first low level action of the shortest
+// safe route going through target. It returns an empty string when target
+// cannot be reached safely.
+func (g *Game) Navigate(s *Ship, target Offset) string {
+	type node struct {
+		ship   Ship
+		action string // first action of the route
+		depth  int
+	}
+	var visited [MapWidth][MapHeight][6][MaxShipSpeed + 1]bool
+	h := g.hazards(s)
+	queue := []node{{ship: *s}}
+	visited[s.X][s.Y][s.Rotation][s.Speed] = true
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.depth >= NavigationDepth {
+			break
+		}
+		for _, a := range lowLevelActions {
+			next, reached, safe := h.sail(n.ship, a, g.Round+n.depth, target)
+			if !safe {
+				continue
+			}
+			first := n.action
+			if first == "" {
+				first = a
+			}
+			if reached {
+				return first
+			}
+			if visited[next.X][next.Y][next.Rotation][next.Speed] {
+				continue
+			}
+			visited[next.X][next.Y][next.Rotation][next.Speed] = true
+			queue = append(queue, node{ship: next, action: first, depth: n.depth + 1})
+		}
+	}
+	return ""
+}
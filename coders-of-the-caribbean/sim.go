@@ -0,0 +1,348 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxShipSpeed = 2
+	MaxShipRhum  = 100
+	WreckRhum    = 30 // most rum left in a barrel by a sunk ship
+
+	MineDamage           = 25
+	MineNearDamage       = 10
+	CannonballDamage     = 50
+	CannonballNearDamage = 25 // bow and stern
+)
+
+// Simulation replays the referee rules on a copy of the game. Every round
+// the referee makes the ships lose one rum, executes the actions, moves
+// the ships one cell at a time, rotates them and finally makes the
+// cannonballs land.
+//
+// Objects are stored by value so a simulation can be cloned and stepped
+// without touching the game.
+type Simulation struct {
+	Round       int
+	Ships       []Ship
+	Barrels     []Barrel
+	Mines       []Mine
+	Cannonballs []Cannonball
+	Damage      map[int]int // rum lost by every ship to mines and cannonballs
+}
+
+func NewSimulation(g *Game) *Simulation {
+	sim := &Simulation{
+		Round:       g.Round,
+		Ships:       make([]Ship, 0, len(g.Ships)),
+		Barrels:     make([]Barrel, 0, len(g.Barrels)),
+		Mines:       make([]Mine, 0, len(g.Mines)),
+		Cannonballs: make([]Cannonball, 0, len(g.Cannonballs)),
+		Damage:      make(map[int]int),
+	}
+	for _, s := range g.Ships {
+		sim.Ships = append(sim.Ships, *s)
+	}
+	for _, b := range g.Barrels {
+		sim.Barrels = append(sim.Barrels, *b)
+	}
+	for _, m := range g.Mines {
+		sim.Mines = append(sim.Mines, *m)
+	}
+	for _, c := range g.Cannonballs {
+		sim.Cannonballs = append(sim.Cannonballs, *c)
+	}
+	return sim
+}
+
+func (sim *Simulation) Clone() *Simulation {
+	c := &Simulation{
+		Round:       sim.Round,
+		Ships:       append([]Ship(nil), sim.Ships...),
+		Barrels:     append([]Barrel(nil), sim.Barrels...),
+		Mines:       append([]Mine(nil), sim.Mines...),
+		Cannonballs: append([]Cannonball(nil), sim.Cannonballs...),
+		Damage:      make(map[int]int, len(sim.Damage)),
+	}
+	for id, d := range sim.Damage {
+		c.Damage[id] = d
+	}
+	return c
+}
+
+// Ship returns the ship with the given id, or nil once it sank.
+func (sim *Simulation) Ship(id int) *Ship {
+	for i := range sim.Ships {
+		if sim.Ships[i].id == id {
+			return &sim.Ships[i]
+		}
+	}
+	return nil
+}
+
+// Step plays one round with the actions of the ships, indexed by ship id.
+// Ships without an action WAIT.
+func (sim *Simulation) Step(actions map[int]string) {
+	rhum := make([]int, len(sim.Ships))
+	rotations := make([]int, len(sim.Ships))
+	for i := range sim.Ships {
+		s := &sim.Ships[i]
+		s.Rhum--
+		rhum[i] = s.Rhum
+		rotations[i] = sim.apply(s, actions[s.id])
+	}
+	for step := 1; step <= MaxShipSpeed; step++ {
+		sim.move(step)
+	}
+	sim.rotate(rotations)
+	sim.explode()
+	sim.sink(rhum)
+	sim.Round++
+}
+
+// apply executes the action of the ship and returns its rotation at the
+// end of the round.
+func (sim *Simulation) apply(s *Ship, action string) int {
+	args := strings.Fields(action)
+	if len(args) == 0 {
+		return s.Rotation
+	}
+	switch args[0] {
+	case "FASTER":
+		if s.Speed < MaxShipSpeed {
+			s.Speed++
+		}
+	case "SLOWER":
+		if s.Speed > 0 {
+			s.Speed--
+		}
+	case "PORT":
+		return (s.Rotation + 1) % 6
+	case "STARBOARD":
+		return (s.Rotation + 5) % 6
+	case "MINE":
+		pos := s.Stern().Neighbor((s.Rotation + 3) % 6)
+		if pos.Inside() && !sim.occupied(pos) {
+			sim.Mines = append(sim.Mines, Mine{GameObject: GameObject{Offset: pos}})
+		}
+	case "FIRE":
+		if len(args) < 3 {
+			break
+		}
+		x, _ := strconv.Atoi(args[1])
+		y, _ := strconv.Atoi(args[2])
+		target := Offset{X: x, Y: y}
+		bow := s.Bow()
+		if target.Inside() && bow.Dist(target) <= FireDistanceMax {
+			sim.Cannonballs = append(sim.Cannonballs, Cannonball{
+				GameObject: GameObject{Offset: target},
+				Ship:       s.id,
+				Impact:     sim.Round + TravelTime(bow, target),
+			})
+		}
+	}
+	return s.Rotation
+}
+
+// occupied reports whether a ship, a barrel or a mine lies on the cell.
+func (sim *Simulation) occupied(pos Offset) bool {
+	for i := range sim.Ships {
+		if sim.Ships[i].At(pos) {
+			return true
+		}
+	}
+	for _, b := range sim.Barrels {
+		if b.Offset == pos {
+			return true
+		}
+	}
+	for _, m := range sim.Mines {
+		if m.Offset == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// move moves forward every ship going at least at the given speed. Ships
+// leaving the map or whose bow runs into another ship stay in place and
+// stop.
+func (sim *Simulation) move(step int) {
+	next := make([]Ship, len(sim.Ships))
+	for i, s := range sim.Ships {
+		next[i] = s
+		if step > s.Speed {
+			continue
+		}
+		pos := s.Bow()
+		if !pos.Inside() {
+			sim.Ships[i].Speed = 0
+			next[i].Speed = 0
+			continue
+		}
+		next[i].Offset = pos
+	}
+	for collision := true; collision; {
+		collision = false
+		for i := range next {
+			if next[i].Offset == sim.Ships[i].Offset || !collides(next, i, next[i].Bow()) {
+				continue
+			}
+			next[i] = sim.Ships[i]
+			next[i].Speed = 0
+			sim.Ships[i].Speed = 0
+			collision = true
+		}
+	}
+	copy(sim.Ships, next)
+	for i := range sim.Ships {
+		sim.pickup(i)
+	}
+}
+
+// rotate turns the ships. Ships whose bow or stern runs into another ship
+// keep their rotation and stop.
+func (sim *Simulation) rotate(rotations []int) {
+	next := make([]Ship, len(sim.Ships))
+	for i, s := range sim.Ships {
+		next[i] = s
+		next[i].Rotation = rotations[i]
+	}
+	for collision := true; collision; {
+		collision = false
+		for i := range next {
+			if next[i].Rotation == sim.Ships[i].Rotation {
+				continue
+			}
+			if !collides(next, i, next[i].Bow()) && !collides(next, i, next[i].Stern()) {
+				continue
+			}
+			next[i] = sim.Ships[i]
+			next[i].Speed = 0
+			collision = true
+		}
+	}
+	copy(sim.Ships, next)
+	for i := range sim.Ships {
+		sim.pickup(i)
+	}
+}
+
+// collides reports whether a ship other than the i-th one lies on pos.
+func collides(ships []Ship, i int, pos Offset) bool {
+	for j := range ships {
+		if j != i && ships[j].At(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// pickup makes the i-th ship drink the barrels and trigger the mines it
+// lies on.
+func (sim *Simulation) pickup(i int) {
+	s := &sim.Ships[i]
+	barrels := sim.Barrels[:0]
+	for _, b := range sim.Barrels {
+		if !s.At(b.Offset) {
+			barrels = append(barrels, b)
+			continue
+		}
+		s.Rhum = min(s.Rhum+b.Rhum, MaxShipRhum)
+	}
+	sim.Barrels = barrels
+	mines := sim.Mines[:0]
+	for _, m := range sim.Mines {
+		if !s.At(m.Offset) {
+			mines = append(mines, m)
+			continue
+		}
+		sim.mine(m.Offset)
+	}
+	sim.Mines = mines
+}
+
+// mine makes the mine at pos explode, damaging the ships on it and next to
+// it.
+func (sim *Simulation) mine(pos Offset) {
+	for i := range sim.Ships {
+		s := &sim.Ships[i]
+		if s.At(pos) {
+			sim.damage(s, MineDamage)
+			continue
+		}
+		for dir := 0; dir < 6; dir++ {
+			if s.At(pos.Neighbor(dir)) {
+				sim.damage(s, MineNearDamage)
+				break
+			}
+		}
+	}
+}
+
+// explode makes the cannonballs landing this round hit the ships, mines
+// and barrels on their cell.
+func (sim *Simulation) explode() {
+	balls := sim.Cannonballs[:0]
+	for _, c := range sim.Cannonballs {
+		if c.Impact > sim.Round {
+			balls = append(balls, c)
+			continue
+		}
+		if c.Impact < sim.Round {
+			continue
+		}
+		for i := range sim.Ships {
+			s := &sim.Ships[i]
+			switch {
+			case s.Offset == c.Offset:
+				sim.damage(s, CannonballDamage)
+			case s.At(c.Offset):
+				sim.damage(s, CannonballNearDamage)
+			}
+		}
+		mines := sim.Mines[:0]
+		for _, m := range sim.Mines {
+			if m.Offset == c.Offset {
+				sim.mine(m.Offset)
+				continue
+			}
+			mines = append(mines, m)
+		}
+		sim.Mines = mines
+		barrels := sim.Barrels[:0]
+		for _, b := range sim.Barrels {
+			if b.Offset != c.Offset {
+				barrels = append(barrels, b)
+			}
+		}
+		sim.Barrels = barrels
+	}
+	sim.Cannonballs = balls
+}
+
+func (sim *Simulation) damage(s *Ship, rhum int) {
+	s.Rhum -= rhum
+	sim.Damage[s.id] += rhum
+}
+
+// sink removes the ships out of rum, leaving a barrel with part of the
+// rum they had once the round decay was applied, if any.
+func (sim *Simulation) sink(rhum []int) {
+	ships := sim.Ships[:0]
+	for i, s := range sim.Ships {
+		if s.Rhum > 0 {
+			ships = append(ships, s)
+			continue
+		}
+		if rhum[i] <= 0 {
+			continue
+		}
+		sim.Barrels = append(sim.Barrels, Barrel{
+			GameObject: s.GameObject,
+			Rhum:       min(WreckRhum, rhum[i]),
+		})
+	}
+	sim.Ships = ships
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// attack is a coordinated shot on a factory: every source sends its wave
+// at the right turn so that all the waves land together.
+type attack struct {
+	Target  int
+	Arrival int         // turn the waves land on the target
+	Waves   map[int]int // cyborgs still to send by every source factory
+}
+
+func (a *attack) String() string {
+	return fmt.Sprintf("{Target: %d, Arrival: %d, Waves: %v}", a.Target, a.Arrival, a.Waves)
+}
+
+// surplus returns the cyborgs a player factory can send this turn while
+// keeping the garrison it needs against the troops heading to it.
+func (g *Game) surplus(f *factory) int {
+	surplus := g.timeline(f).Surplus()
+	if surplus > f.Cyborg {
+		surplus = f.Cyborg
+	}
+	return surplus
+}
+
+// route returns the factory to send troops to on their way to dst, and the
+// turns they need to get there. Troops follow the shortest path when every
+// factory on the way is ours to relay them, otherwise they go straight.
+func (g *Game) route(src, dst int) (next, dist int) {
+	path := g.Paths.Path(src, dst)
+	if len(path) == 0 {
+		return dst, g.Board[src][dst]
+	}
+	for _, id := range path[:len(path)-1] {
+		if g.Factories[id].Faction != playerFaction {
+			return dst, g.Board[src][dst]
+		}
+	}
+	return path[0], g.Paths.Dist(src, dst)
+}
+
+// available returns the surplus of every player factory, minus the waves
+// it has to send later on.
+func (g *Game) available() map[int]int {
+	available := make(map[int]int, len(g.PlayerF))
+	for _, f := range g.PlayerF {
+		available[f.ID] = g.surplus(f)
+	}
+	for _, a := range g.Attacks {
+		for src, cyborg := range a.Waves {
+			if _, dist := g.route(src, a.Target); g.Turn < a.Arrival-dist {
+				available[src] -= cyborg
+			}
+		}
+	}
+	return available
+}
+
+// launchAttacks sends the waves due this turn. A wave going through another
+// factory is handed over to it, the factory sends it on the turn it lands.
+// Attacks are dropped once every wave is sent, or as soon as a source is
+// lost.
+func (g *Game) launchAttacks() {
+	attacks := g.Attacks[:0]
+	for _, a := range g.Attacks {
+		lost := false
+		for src := range a.Waves {
+			lost = lost || g.Factories[src].Faction != playerFaction
+		}
+		if lost {
+			fmt.Fprintln(os.Stderr, "drop attack:", a)
+			continue
+		}
+		for src, cyborg := range a.Waves {
+			next, dist := g.route(src, a.Target)
+			if g.Turn < a.Arrival-dist {
+				continue
+			}
+			f := g.Factories[src]
+			if surplus := g.surplus(f); cyborg > surplus {
+				cyborg = surplus
+			}
+			if cyborg > 0 {
+				g.Order(order{Kind: orderMove, Src: src, Dst: next, Cyborg: cyborg})
+				f.Cyborg -= cyborg
+				if next != a.Target {
+					a.Waves[next] += cyborg
+				}
+			}
+			delete(a.Waves, src)
+		}
+		if len(a.Waves) > 0 {
+			attacks = append(attacks, a)
+		}
+	}
+	g.Attacks = attacks
+}
+
+// attacked reports whether an attack is already planned on the factory.
+func (g *Game) attacked(f *factory) bool {
+	for _, a := range g.Attacks {
+		if a.Target == f.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// planAttack looks for the closest sources able to take the target
+// together. The defenders are forecast at arrival time, including the
+// production and the troops already in flight. Player factories are
+// reinforced instead.
+func (g *Game) planAttack(target *factory, available map[int]int) *attack {
+	var sources []int
+	for _, f := range g.PlayerF {
+		if f.ID != target.ID && available[f.ID] > 0 {
+			sources = append(sources, f.ID)
+		}
+	}
+	dist := make(map[int]int, len(sources))
+	for _, src := range sources {
+		_, dist[src] = g.route(src, target.ID)
+	}
+	sort.Slice(sources, func(i, j int) bool { return dist[sources[i]] < dist[sources[j]] })
+	if target.Faction == playerFaction {
+		return g.planDefense(target, sources, dist, available)
+	}
+
+	total := 0
+	for i, src := range sources {
+		total += available[src]
+		// Troops land at the end of the turn matching their distance.
+		future := g.predict(dist[src] + 1)
+		defender := future.Factories[target.ID]
+		if defender.Faction == playerFaction {
+			return nil
+		}
+		need := defender.Cyborg + 1
+		if total < need {
+			continue
+		}
+		a := &attack{
+			Target:  target.ID,
+			Arrival: g.Turn + dist[src],
+			Waves:   make(map[int]int),
+		}
+		for _, s := range sources[:i+1] {
+			cyborg := available[s]
+			if cyborg > need {
+				cyborg = need
+			}
+			a.Waves[s] = cyborg
+			available[s] -= cyborg
+			need -= cyborg
+		}
+		return a
+	}
+	return nil
+}
+
+// planDefense reinforces a player factory forecast to fall. The waves are
+// sized to the deficit and land at the latest on the turn it falls, from
+// the closest sources able to make it in time.
+func (g *Game) planDefense(target *factory, sources []int, dist, available map[int]int) *attack {
+	deficit, fall := g.timeline(target).Deficit()
+	if deficit == 0 {
+		return nil
+	}
+	total := 0
+	for i, src := range sources {
+		// Troops sent now land at the end of the turn after their distance.
+		if dist[src]+1 > fall {
+			break
+		}
+		total += available[src]
+		if total < deficit {
+			continue
+		}
+		a := &attack{
+			Target:  target.ID,
+			Arrival: g.Turn + dist[src],
+			Waves:   make(map[int]int),
+		}
+		need := deficit
+		for _, s := range sources[:i+1] {
+			cyborg := available[s]
+			if cyborg > need {
+				cyborg = need
+			}
+			a.Waves[s] = cyborg
+			available[s] -= cyborg
+			need -= cyborg
+		}
+		return a
+	}
+	return nil
+}
+
+// planAttacks starts new attacks with the cyborgs left, from the best
+// target of every factory.
+func (g *Game) planAttacks(available map[int]int) {
+	for _, f := range g.PlayerF {
+		if available[f.ID] <= 0 {
+			continue
+		}
+		for _, t := range g.searchBestShots(f) {
+			if t.ID == f.ID || g.attacked(t) {
+				continue
+			}
+			a := g.planAttack(t, available)
+			if a == nil {
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "attack:", a)
+			if t.Faction == opponentFaction {
+				g.Order(order{Kind: orderMsg, Msg: "Attak!"})
+			}
+			g.Attacks = append(g.Attacks, a)
+			break
+		}
+	}
+	g.launchAttacks()
+}
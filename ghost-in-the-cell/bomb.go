@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// bombMinValue is the lowest value worth a bomb: two cyborgs of production
+// lost for the whole production stop.
+const bombMinValue = 2 * disabledTurns
+
+// bombDamage returns the number of cyborgs destroyed by a bomb.
+func bombDamage(cyborg int) int {
+	damage := cyborg / 2
+	if damage < bombMinDamage {
+		damage = bombMinDamage
+	}
+	if damage > cyborg {
+		damage = cyborg
+	}
+	return damage
+}
+
+// bombValue returns what a bomb landing on the factory costs the opponent:
+// its production during the stop plus the cyborgs destroyed.
+func bombValue(f factory) int {
+	if f.Faction != opponentFaction || f.Disabled > 0 {
+		return 0
+	}
+	return f.Prod*disabledTurns + bombDamage(f.Cyborg)
+}
+
+// planBomb launches a bomb from the closest player factory on the opponent
+// factory worth the most at impact time. The forecast includes the troops
+// in flight, so factories about to be taken or reinforced are valued as
+// they will be. Bombs are held while no target is worth it.
+func (g *Game) planBomb() {
+	if g.Bomb.Timer > 0 || g.Bomb.Count <= 0 || len(g.PlayerF) == 0 {
+		return
+	}
+	var target, src *factory
+	best := bombMinValue - 1
+	for _, f := range g.Factories {
+		if f.Faction == playerFaction || g.bombed(f) || g.attacked(f) {
+			continue
+		}
+		var from *factory
+		for _, p := range g.PlayerF {
+			if from == nil || g.Board[p.ID][f.ID] < g.Board[from.ID][f.ID] {
+				from = p
+			}
+		}
+		// The bomb explodes at the end of the turn matching its distance,
+		// once the battles are solved.
+		future := g.predict(g.Board[from.ID][f.ID] + 1)
+		if value := bombValue(future.Factories[f.ID]); value > best {
+			target, src, best = f, from, value
+		}
+	}
+	if target == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "bomb:", target, "from:", src, "value:", best)
+	g.Bomb.Timer = bombTime + g.Board[src.ID][target.ID]
+	g.Order(order{Kind: orderBomb, Src: src.ID, Dst: target.ID})
+	g.Bomb.Count--
+}
@@ -0,0 +1,144 @@
+package main
+
+import "testing"
+
+// newTestGame builds the state of a turn from the factories and troops the
+// referee would send. Every factory is linked to the others, board gives
+// the distances.
+func newTestGame(board [][]int, factories []factory, troops []troop) *Game {
+	g := &Game{
+		Board:        board,
+		FactoryCount: len(board),
+		Factories:    make(map[int]*factory),
+		Troops:       make(map[int]*troop),
+		Bombs:        make(map[int]*bomb),
+		BombSeen:     make(map[int]int),
+		Paths:        newPaths(board),
+	}
+	g.Bomb.Count = 2
+	for i := range factories {
+		f := factories[i]
+		g.Factories[f.ID] = &f
+		switch f.Faction {
+		case neutralFaction:
+			g.NeutralF = append(g.NeutralF, &f)
+		case playerFaction:
+			g.PlayerF = append(g.PlayerF, &f)
+		case opponentFaction:
+			g.OpponentF = append(g.OpponentF, &f)
+		}
+	}
+	for i := range troops {
+		t := troops[i]
+		g.Troops[t.ID] = &t
+	}
+	g.upateTroops()
+	g.Sim = newSimulation(g)
+	g.updateForecast()
+	return g
+}
+
+func TestBombDamage(t *testing.T) {
+	tests := []struct {
+		cyborg, want int
+	}{
+		{0, 0},
+		{3, 3},
+		{9, 9},
+		{10, 10},
+		{19, 10},
+		{20, 10},
+		{21, 10},
+		{22, 11},
+		{100, 50},
+		{999, 499},
+	}
+	for _, tt := range tests {
+		if got := bombDamage(tt.cyborg); got != tt.want {
+			t.Errorf("bombDamage(%d) = %d, want %d", tt.cyborg, got, tt.want)
+		}
+	}
+}
+
+func TestBombValue(t *testing.T) {
+	tests := []struct {
+		name string
+		f    factory
+		want int
+	}{
+		{"neutral", factory{Faction: neutralFaction, Cyborg: 30, Prod: 3}, 0},
+		{"player", factory{Faction: playerFaction, Cyborg: 30, Prod: 3}, 0},
+		{"disabled", factory{Faction: opponentFaction, Cyborg: 30, Prod: 3, Disabled: 2}, 0},
+		{"empty", factory{Faction: opponentFaction, Prod: 2}, 2 * disabledTurns},
+		{"few cyborgs", factory{Faction: opponentFaction, Cyborg: 4}, 4},
+		{"garrison", factory{Faction: opponentFaction, Cyborg: 40, Prod: 1}, disabledTurns + 20},
+	}
+	for _, tt := range tests {
+		if got := bombValue(tt.f); got != tt.want {
+			t.Errorf("%s: bombValue() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBomb(t *testing.T) {
+	board := [][]int{
+		{0, 3, 6},
+		{3, 0, 4},
+		{6, 4, 0},
+	}
+	tests := []struct {
+		name      string
+		factories []factory
+		want      *order
+	}{
+		{
+			name: "no opponent factory",
+			factories: []factory{
+				{ID: 0, Faction: playerFaction, Cyborg: 20, Prod: 2},
+				{ID: 1, Faction: neutralFaction, Cyborg: 5, Prod: 3},
+				{ID: 2, Faction: neutralFaction, Cyborg: 30, Prod: 2},
+			},
+		},
+		{
+			name: "no player factory",
+			factories: []factory{
+				{ID: 0, Faction: neutralFaction, Cyborg: 20, Prod: 2},
+				{ID: 1, Faction: opponentFaction, Cyborg: 30, Prod: 3},
+				{ID: 2, Faction: opponentFaction, Cyborg: 30, Prod: 3},
+			},
+		},
+		{
+			name: "below min value",
+			factories: []factory{
+				{ID: 0, Faction: playerFaction, Cyborg: 20, Prod: 2},
+				{ID: 1, Faction: opponentFaction, Cyborg: 2},
+				{ID: 2, Faction: opponentFaction, Cyborg: 0, Prod: 1, Disabled: 3},
+			},
+		},
+		{
+			name: "most productive",
+			factories: []factory{
+				{ID: 0, Faction: playerFaction, Cyborg: 20, Prod: 2},
+				{ID: 1, Faction: opponentFaction, Cyborg: 2, Prod: 1},
+				{ID: 2, Faction: opponentFaction, Cyborg: 10, Prod: 3},
+			},
+			want: &order{Kind: orderBomb, Src: 0, Dst: 2},
+		},
+	}
+	for _, tt := range tests {
+		g := newTestGame(board, tt.factories, nil)
+		g.planBomb()
+		if tt.want == nil {
+			if len(g.Orders) != 0 || g.Bomb.Count != 2 {
+				t.Errorf("%s: orders %v, %d bombs left, want none sent", tt.name, g.Orders, g.Bomb.Count)
+			}
+			continue
+		}
+		if len(g.Orders) != 1 || g.Orders[0] != *tt.want {
+			t.Errorf("%s: orders %v, want %v", tt.name, g.Orders, *tt.want)
+		}
+		if g.Bomb.Count != 1 || g.Bomb.Timer <= 0 {
+			t.Errorf("%s: %d bombs left, timer %d, want 1 left and a timer", tt.name, g.Bomb.Count, g.Bomb.Timer)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -16,29 +17,28 @@ const (
 	bombTime         = 5
 )
 
-var game Game
-
 type factory struct {
-	ID      int
-	Faction int
-	Cyborg  int
-	Prod    int
-	Troops  struct {
+	ID       int
+	Faction  int
+	Cyborg   int
+	Prod     int
+	Disabled int // turns before production restarts
+	Troops   struct {
 		Player   int
 		Opponent int
 	}
+	Estimated int // see EstimatedCyborg
 }
 
 func (f *factory) String() string {
 	return fmt.Sprintf("{ID: %d, Cy: %d, Es: %d}", f.ID, f.Cyborg, f.EstimatedCyborg())
 }
 
+// EstimatedCyborg returns the number of cyborgs left in the factory once
+// every troop in flight has landed. It is negative when the factory changes
+// faction.
 func (f *factory) EstimatedCyborg() int {
-	cyborg := f.Cyborg - f.Troops.Player + f.Troops.Opponent
-	if f.Faction == playerFaction {
-		cyborg = f.Cyborg + f.Troops.Player - f.Troops.Opponent
-	}
-	return cyborg
+	return f.Estimated
 }
 
 type troop struct {
@@ -54,12 +54,6 @@ func (t *troop) String() string {
 	return fmt.Sprintf("{Turns: %d}", t.Turns)
 }
 
-type path struct {
-	Dist    []int // distance to every factory
-	Prev    []int // previous factory on the path
-	Closest []int // factories ordered by distance
-}
-
 type Game struct {
 	Board [][]int
 
@@ -71,14 +65,21 @@ type Game struct {
 
 	TroopMaxID int
 	Troops     map[int]*troop
+	Bombs      map[int]*bomb
+	BombSeen   map[int]int // turn every bomb was first seen
 
 	Bomb struct {
 		Count int
 		Timer int
 	}
 
-	Turn int
-	Path []path
+	Turn  int
+	Paths *paths
+
+	Attacks  []*attack   // attacks in progress
+	Orders   []order     // orders given this turn
+	Sim      *simulation // state sent by the referee this turn
+	Forecast *simulation // state once every troop has landed
 }
 
 func (g *Game) String() string {
@@ -92,77 +93,103 @@ func (g *Game) String() string {
 	return str
 }
 
-func new2DSlice(n, m int) [][]int {
-	tmp := make([]int, n*m)
-	slice := make([][]int, n)
-	for i := range slice {
-		slice[i] = tmp[i*m : (i+1)*m]
-	}
-	return slice
+// Order queues an order for this turn and updates the forecast.
+func (g *Game) Order(o order) {
+	g.Orders = append(g.Orders, o)
+	g.updateForecast()
 }
 
-func dijkstra(src int) (dist, prev []int) {
-	unvisited := make(map[int]struct{})
-	dist = make([]int, game.FactoryCount)
-	prev = make([]int, game.FactoryCount)
-	for i := 0; i < game.FactoryCount; i++ {
-		dist[i] = maxDistance
-		prev[i] = invalidPath
-		unvisited[i] = struct{}{}
+// predict returns the state of the game in the given number of turns,
+// once the orders of this turn are executed.
+func (g *Game) predict(turns int) *simulation {
+	s := g.Sim.Clone()
+	s.Apply(playerFaction, g.Orders)
+	if turns < 0 {
+		turns = s.Horizon()
 	}
-	dist[src] = 0
-	for len(unvisited) > 0 {
-		min := -1
-		for i := range unvisited {
-			if min == -1 || dist[i] < dist[min] {
-				min = i
-			}
-		}
-		delete(unvisited, min)
+	s.Run(turns)
+	return s
+}
 
-		for v := range game.Board[min] {
-			alt := dist[min] + game.Board[min][v]
-			if alt < dist[v] {
-				dist[v] = alt
-				prev[v] = min
-			}
+func (g *Game) updateForecast() {
+	g.Forecast = g.predict(-1)
+	for id, f := range g.Factories {
+		future := g.Forecast.Factories[id]
+		f.Estimated = future.Cyborg
+		if future.Faction != f.Faction {
+			f.Estimated = -future.Cyborg
 		}
 	}
-	return
 }
 
-func pathToDst(prev []int, dst int) []int {
-	path := make([]int, len(prev))
-	i := dst
-	j := len(prev) - 1
-	for prev[i] != invalidPath {
-		path[j] = i
-		i = prev[i]
-		j--
+// Clone returns a deep copy of the game. The board and the paths never
+// change once computed, they are shared.
+func (g *Game) Clone() *Game {
+	c := *g
+	c.Factories = make(map[int]*factory, len(g.Factories))
+	for id, f := range g.Factories {
+		cf := *f
+		c.Factories[id] = &cf
+	}
+	c.NeutralF = c.factories(g.NeutralF)
+	c.OpponentF = c.factories(g.OpponentF)
+	c.PlayerF = c.factories(g.PlayerF)
+	c.Troops = make(map[int]*troop, len(g.Troops))
+	for id, t := range g.Troops {
+		ct := *t
+		c.Troops[id] = &ct
+	}
+	c.Bombs = make(map[int]*bomb, len(g.Bombs))
+	for id, b := range g.Bombs {
+		cb := *b
+		c.Bombs[id] = &cb
+	}
+	c.BombSeen = make(map[int]int, len(g.BombSeen))
+	for id, turn := range g.BombSeen {
+		c.BombSeen[id] = turn
 	}
-	return path[j+1:]
+	c.Attacks = make([]*attack, len(g.Attacks))
+	for i, a := range g.Attacks {
+		ca := *a
+		ca.Waves = make(map[int]int, len(a.Waves))
+		for src, cyborg := range a.Waves {
+			ca.Waves[src] = cyborg
+		}
+		c.Attacks[i] = &ca
+	}
+	c.Orders = append([]order(nil), g.Orders...)
+	if g.Sim != nil {
+		c.Sim = g.Sim.Clone()
+	}
+	if g.Forecast != nil {
+		c.Forecast = g.Forecast.Clone()
+	}
+	return &c
 }
 
-func sortIndex(dist []int) []int {
-	ids := make([]int, len(dist))
-	for i := range ids {
-		ids[i] = i
+// factories returns the factories of g with the same IDs.
+func (g *Game) factories(src []*factory) []*factory {
+	dst := make([]*factory, len(src))
+	for i, f := range src {
+		dst[i] = g.Factories[f.ID]
 	}
-	for i := range ids {
-		for j := range dist {
-			if dist[ids[j]] > dist[ids[i]] {
-				ids[i], ids[j] = ids[j], ids[i]
-			}
-		}
+	return dst
+}
+
+func new2DSlice(n, m int) [][]int {
+	tmp := make([]int, n*m)
+	slice := make([][]int, n)
+	for i := range slice {
+		slice[i] = tmp[i*m : (i+1)*m]
 	}
-	return ids
+	return slice
 }
 
 // upateTroops compute number of cyborgs in all factories
 // once all the troops reach destination.
-func upateTroops() {
-	for _, t := range game.Troops {
-		f := game.Factories[t.Dst]
+func (g *Game) upateTroops() {
+	for _, t := range g.Troops {
+		f := g.Factories[t.Dst]
 		if t.Faction == opponentFaction {
 			f.Troops.Opponent += t.Cyborg
 		} else if t.Faction == playerFaction {
@@ -171,56 +198,101 @@ func upateTroops() {
 	}
 }
 
-func searchClosestTroops(src *factory) []*troop {
-	var troops []*troop
-	for _, t := range game.Troops {
-		if src.ID == t.Dst && t.Faction == opponentFaction {
-			troops = append(troops, t)
+// guessBombTarget sets the target of an opponent bomb: the most productive
+// player factory it can still reach given the turns elapsed since launch.
+func (g *Game) guessBombTarget(b *bomb) {
+	elapsed := g.Turn - b.Seen
+	var target *factory
+	for _, f := range g.PlayerF {
+		if g.Board[b.Src][f.ID]-elapsed < 1 {
+			continue
+		}
+		if target == nil || f.Prod > target.Prod || (f.Prod == target.Prod && f.Cyborg > target.Cyborg) {
+			target = f
 		}
 	}
-	for i := range troops {
-		for j := range troops[i:] {
-			if troops[i].Turns < troops[j].Turns {
-				troops[i], troops[j] = troops[j], troops[i]
+	if target == nil {
+		return
+	}
+	b.Dst = target.ID
+	b.Turns = g.Board[b.Src][target.ID] - elapsed
+}
+
+// bombed reports whether one of our bombs is heading to the factory.
+func (g *Game) bombed(f *factory) bool {
+	for _, b := range g.Bombs {
+		if b.Faction == playerFaction && b.Dst == f.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// evacuate moves the cyborgs out of the player factories an opponent bomb
+// hits at the end of this turn.
+func (g *Game) evacuate() {
+	for _, b := range g.Bombs {
+		if b.Faction != opponentFaction || b.Turns != 1 {
+			continue
+		}
+		f := g.Factories[b.Dst]
+		if f.Faction != playerFaction || f.Cyborg == 0 {
+			continue
+		}
+		dst := invalidPath
+		for _, id := range g.Paths.Closest(f.ID) {
+			if id == f.ID || g.Factories[id].Faction == opponentFaction {
+				continue
 			}
+			if dst == invalidPath || g.Factories[id].Faction == playerFaction {
+				dst = id
+			}
+			if g.Factories[id].Faction == playerFaction {
+				break
+			}
+		}
+		if dst == invalidPath {
+			continue
 		}
+		fmt.Fprintln(os.Stderr, "evacuate:", f, "bomb:", b)
+		g.Order(order{Kind: orderMove, Src: f.ID, Dst: dst, Cyborg: f.Cyborg})
+		f.Cyborg = 0
 	}
-	return troops
 }
 
-func searchBestShots(src *factory) []*factory {
+func (g *Game) searchBestShots(src *factory) []*factory {
 	// Get target factories.
-	targets := make([]*factory, 0, game.FactoryCount)
-	for _, f := range game.NeutralF {
-		if f.Prod < 1 || f.Cyborg-f.Troops.Player < 0 {
+	targets := make([]*factory, 0, g.FactoryCount)
+	for _, f := range g.NeutralF {
+		if !g.worthCapture(f) || f.Cyborg-f.Troops.Player < 0 || g.bombed(f) {
 			continue
 		}
 		targets = append(targets, f)
 	}
 
-	for _, f := range game.PlayerF {
-		if f.Prod < 1 || f.EstimatedCyborg() >= 0 {
+	for _, f := range g.PlayerF {
+		if f.Prod < 1 {
 			continue
 		}
-		// compute NB tour before impact.
-		closest := searchClosestTroops(f)
-		fmt.Fprintln(os.Stderr, closest)
-		if f.Prod*closest[0].Turns+f.Cyborg >= closest[0].Cyborg {
+		if deficit, _ := g.timeline(f).Deficit(); deficit == 0 {
 			continue
 		}
 		targets = append(targets, f)
 	}
 
 	if len(targets) == 0 {
-		targets = append(targets, game.OpponentF...)
+		for _, f := range g.OpponentF {
+			if !g.bombed(f) {
+				targets = append(targets, f)
+			}
+		}
 	}
 
 	// Order by faction, prod, dist.
-	dist := game.Path[src.ID].Dist
 	for i := range targets {
 		for j := range targets[i:] {
 			swap := false
-			swap = swap || dist[targets[i].ID] < dist[targets[j].ID]
+			swap = swap || g.Paths.Dist(src.ID, targets[i].ID) < g.Paths.Dist(src.ID, targets[j].ID)
 			swap = swap || targets[i].Prod > targets[j].Prod
 			//swap = swap || targets[i].Faction == opponentFaction
 			if swap {
@@ -233,12 +305,15 @@ func searchBestShots(src *factory) []*factory {
 }
 
 func main() {
+	game := &Game{}
+
 	// factoryCount: the number of factories
 	var factoryCount int
 	fmt.Scan(&factoryCount)
 	game.FactoryCount = factoryCount
 	game.Board = new2DSlice(factoryCount, factoryCount)
 	game.Bomb.Count = 2
+	game.BombSeen = make(map[int]int)
 
 	// linkCount: the number of links between factories
 	var linkCount int
@@ -250,23 +325,19 @@ func main() {
 		game.Board[factory1][factory2] = distance
 		game.Board[factory2][factory1] = distance
 	}
-	fmt.Fprintln(os.Stderr, &game)
-
-	game.Path = make([]path, factoryCount)
-	for i := range game.Path {
-		dist, prev := dijkstra(i)
-		game.Path[i].Dist = dist
-		game.Path[i].Prev = prev
-		game.Path[i].Closest = sortIndex(dist)
-	}
+	fmt.Fprintln(os.Stderr, game)
+
+	game.Paths = newPaths(game.Board)
 
 	for {
-		tstart := time.Now()
 		// entityCount: the number of entities (e.g. factories and troops)
 		var entityCount int
 		fmt.Scan(&entityCount)
+		// The turn timer starts once the referee sends the input.
+		tstart := time.Now()
 
 		game.Troops = make(map[int]*troop)
+		game.Bombs = make(map[int]*bomb)
 		game.Factories = make(map[int]*factory)
 		game.NeutralF = make([]*factory, 0, game.FactoryCount)
 		game.PlayerF = make([]*factory, 0, game.FactoryCount)
@@ -287,15 +358,29 @@ func main() {
 				}
 				game.Troops[t.ID] = t
 				game.TroopMaxID = t.ID
-			} else if entityType == "FACTORY" {
-				f := &factory{
+			} else if entityType == "BOMB" {
+				b := &bomb{
 					ID:      entityID,
 					Faction: arg1,
-					Cyborg:  arg2,
-					Prod:    arg3,
+					Src:     arg2,
+					Dst:     arg3,
+					Turns:   arg4,
+				}
+				if _, ok := game.BombSeen[b.ID]; !ok {
+					game.BombSeen[b.ID] = game.Turn
+				}
+				b.Seen = game.BombSeen[b.ID]
+				game.Bombs[b.ID] = b
+			} else if entityType == "FACTORY" {
+				f := &factory{
+					ID:       entityID,
+					Faction:  arg1,
+					Cyborg:   arg2,
+					Prod:     arg3,
+					Disabled: arg4,
 				}
 				game.Factories[f.ID] = f
-				if f.Faction == neutralFaction && f.Prod > 0 {
+				if f.Faction == neutralFaction {
 					game.NeutralF = append(game.NeutralF, f)
 				} else if f.Faction == playerFaction {
 					game.PlayerF = append(game.PlayerF, f)
@@ -304,70 +389,32 @@ func main() {
 				}
 			}
 		}
-		upateTroops()
-
-		action := ""
-		// Throw bomb one at a time.
-		if game.Bomb.Timer <= 0 && game.Bomb.Count > 0 {
-			var target *factory
-			for _, f := range game.OpponentF {
-				if target == nil || f.Prod > target.Prod {
-					target = f
-				}
-			}
-			row := game.Board[target.ID]
-			var src *factory
-			for id, f := range game.PlayerF {
-				if src == nil || row[id] < row[src.ID] {
-					src = f
-				}
+		game.upateTroops()
+		for _, b := range game.Bombs {
+			if b.Faction == opponentFaction {
+				game.guessBombTarget(b)
 			}
-			game.Bomb.Timer = bombTime + row[src.ID]
-			action = fmt.Sprintf("BOMB %d %d; ", src.ID, target.ID)
-			game.Bomb.Count--
 		}
-		for _, f := range game.Factories {
-			if f.Faction != playerFaction {
-				continue
-			}
-			if f.EstimatedCyborg() <= 0 {
-				continue
-			}
-			if len(game.NeutralF) == 0 && f.Troops.Opponent == 0 && f.Cyborg > 15 && f.Prod >= 1 && f.Prod < 3 {
-				action += fmt.Sprintf("INC %d; ", f.ID)
-				continue
-			}
+		game.Orders = nil
+		game.Sim = newSimulation(game)
+		game.updateForecast()
+		game.evacuate()
 
-			// Choose an action.
-			targets := searchBestShots(f)
-			fmt.Fprintln(os.Stderr, "f:", f, "targets:", targets)
-			//fmt.Fprintln(os.Stderr, "dist:", game.Path[f.ID].Dist)
-			for _, t := range targets {
-				if t.ID == f.ID {
-					continue
-				}
-				// fmt.Fprintln(os.Stderr, "prev:", game.Path[f.ID].Prev, "t.ID:", t.ID)
-				path := pathToDst(game.Path[f.ID].Prev, t.ID)
-				// fmt.Fprintln(os.Stderr, "path:", path)
-				cyborg := f.Cyborg
-				fmt.Fprintf(os.Stderr, "- t: %v; cyborg: %d\n", t, cyborg)
-				if cyborg == 0 {
-					continue
-				}
-				if t.Faction == opponentFaction {
-					action += fmt.Sprintf("MSG Attak!; ")
-				}
-				action += fmt.Sprintf("MOVE %d %d %d; ", f.ID, path[0], cyborg)
-				// Improve shot.
-				game.Factories[path[0]].Troops.Player += cyborg
-				f.Cyborg -= cyborg
-				break
+		// Throw bomb one at a time.
+		game.planBomb()
+		game.launchAttacks()
+		available := game.available()
+		game.increase(available)
+		game.planAttacks(available)
+		game.commit(newSearch(game).Run(tstart.Add(searchBudget)))
+
+		action := "WAIT"
+		if len(game.Orders) > 0 {
+			actions := make([]string, len(game.Orders))
+			for i, o := range game.Orders {
+				actions[i] = o.String()
 			}
-		}
-		if action != "" {
-			action = action[:len(action)-2]
-		} else {
-			action = "WAIT"
+			action = strings.Join(actions, "; ")
 		}
 
 		// Any valid action, such as "WAIT" or "MOVE source destination cyborgs"
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestGameClone(t *testing.T) {
+	board := [][]int{
+		{0, 3, 6},
+		{3, 0, 4},
+		{6, 4, 0},
+	}
+	g := newTestGame(board, []factory{
+		{ID: 0, Faction: playerFaction, Cyborg: 20, Prod: 2},
+		{ID: 1, Faction: neutralFaction, Cyborg: 5, Prod: 1},
+		{ID: 2, Faction: opponentFaction, Cyborg: 10, Prod: 3},
+	}, []troop{
+		{ID: 3, Faction: opponentFaction, Src: 2, Dst: 0, Cyborg: 7, Turns: 4},
+	})
+	g.Bombs[4] = &bomb{ID: 4, Faction: playerFaction, Src: 0, Dst: 2, Turns: 5}
+	g.BombSeen[4] = 0
+	g.Attacks = []*attack{{Target: 1, Arrival: 3, Waves: map[int]int{0: 6}}}
+	g.Orders = []order{{Kind: orderMove, Src: 0, Dst: 1, Cyborg: 6}}
+
+	c := g.Clone()
+	for _, list := range [][]*factory{c.PlayerF, c.NeutralF, c.OpponentF} {
+		for _, f := range list {
+			if c.Factories[f.ID] != f {
+				t.Errorf("factory %d of the clone lists is not the one of its map", f.ID)
+			}
+			if g.Factories[f.ID] == f {
+				t.Errorf("factory %d is shared with the original", f.ID)
+			}
+		}
+	}
+
+	c.Factories[0].Cyborg = 99
+	c.NeutralF[0].Faction = playerFaction
+	c.OpponentF[0].Prod = 0
+	c.Troops[3].Cyborg = 99
+	c.Bombs[4].Dst = 1
+	c.BombSeen[4] = 9
+	c.Attacks[0].Waves[0] = 99
+	c.Attacks[0].Arrival = 9
+	c.Orders[0].Cyborg = 99
+	c.Sim.Factories[0].Cyborg = 99
+	c.Forecast.Factories[0].Cyborg = 99
+
+	if g.Factories[0].Cyborg != 20 || g.PlayerF[0].Cyborg != 20 {
+		t.Error("player factory changed with the clone")
+	}
+	if g.Factories[1].Faction != neutralFaction || g.NeutralF[0].Faction != neutralFaction {
+		t.Error("neutral factory changed with the clone")
+	}
+	if g.Factories[2].Prod != 3 || g.OpponentF[0].Prod != 3 {
+		t.Error("opponent factory changed with the clone")
+	}
+	if g.Troops[3].Cyborg != 7 {
+		t.Error("troop changed with the clone")
+	}
+	if g.Bombs[4].Dst != 2 || g.BombSeen[4] != 0 {
+		t.Error("bomb changed with the clone")
+	}
+	if g.Attacks[0].Waves[0] != 6 || g.Attacks[0].Arrival != 3 {
+		t.Error("attack changed with the clone")
+	}
+	if g.Orders[0].Cyborg != 6 {
+		t.Error("order changed with the clone")
+	}
+	if g.Sim.Factories[0].Cyborg != 20 {
+		t.Error("simulation changed with the clone")
+	}
+	if g.Forecast.Factories[0].Cyborg == 99 {
+		t.Error("forecast changed with the clone")
+	}
+}
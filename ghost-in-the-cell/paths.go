@@ -0,0 +1,100 @@
+package main
+
+import "sort"
+
+// unreachable is the distance between factories without any path.
+const unreachable = 1 << 20
+
+// paths holds the shortest paths between every pair of factories. Among
+// paths of the same length the one with the most hops wins: troops going
+// through more factories can be rerouted on the way.
+type paths struct {
+	dist    [][]int
+	hops    [][]int
+	next    [][]int // first factory on the path
+	closest [][]int // factories ordered by distance
+}
+
+// newPaths computes the paths from the board with Floyd-Warshall.
+func newPaths(board [][]int) *paths {
+	n := len(board)
+	p := &paths{
+		dist:    new2DSlice(n, n),
+		hops:    new2DSlice(n, n),
+		next:    new2DSlice(n, n),
+		closest: make([][]int, n),
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			p.next[i][j] = j
+			switch {
+			case i == j:
+			case board[i][j] > 0:
+				p.dist[i][j] = board[i][j]
+				p.hops[i][j] = 1
+			default:
+				p.dist[i][j] = unreachable
+				p.next[i][j] = invalidPath
+			}
+		}
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if i == k || p.dist[i][k] == unreachable {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				if j == i || j == k || p.dist[k][j] == unreachable {
+					continue
+				}
+				dist := p.dist[i][k] + p.dist[k][j]
+				hops := p.hops[i][k] + p.hops[k][j]
+				if dist < p.dist[i][j] || (dist == p.dist[i][j] && hops > p.hops[i][j]) {
+					p.dist[i][j] = dist
+					p.hops[i][j] = hops
+					p.next[i][j] = p.next[i][k]
+				}
+			}
+		}
+	}
+	for i := range p.closest {
+		ids := make([]int, n)
+		for j := range ids {
+			ids[j] = j
+		}
+		dist := p.dist[i]
+		sort.SliceStable(ids, func(a, b int) bool { return dist[ids[a]] < dist[ids[b]] })
+		p.closest[i] = ids
+	}
+	return p
+}
+
+// Dist returns the length of the shortest path.
+func (p *paths) Dist(src, dst int) int {
+	return p.dist[src][dst]
+}
+
+// Next returns the first factory to send troops to in order to reach dst,
+// or invalidPath.
+func (p *paths) Next(src, dst int) int {
+	return p.next[src][dst]
+}
+
+// Path returns the factories of the shortest path, src excluded. It returns
+// nil when dst cannot be reached.
+func (p *paths) Path(src, dst int) []int {
+	var path []int
+	for src != dst {
+		src = p.next[src][dst]
+		if src == invalidPath {
+			return nil
+		}
+		path = append(path, src)
+	}
+	return path
+}
+
+// Closest returns every factory ordered by distance from src, src first.
+func (p *paths) Closest(src int) []int {
+	return p.closest[src]
+}
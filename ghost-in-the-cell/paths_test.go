@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewPaths(t *testing.T) {
+	// 0 -2- 1 -2- 2 -1- 3, with direct links 0-2 (4), 0-3 (10) and 1-3
+	// (6). Factory 4 has no link.
+	board := new2DSlice(5, 5)
+	for _, l := range [][3]int{{0, 1, 2}, {1, 2, 2}, {0, 2, 4}, {2, 3, 1}, {0, 3, 10}, {1, 3, 6}} {
+		board[l[0]][l[1]] = l[2]
+		board[l[1]][l[0]] = l[2]
+	}
+	p := newPaths(board)
+
+	tests := []struct {
+		name     string
+		src, dst int
+		dist     int
+		next     int
+		path     []int
+	}{
+		{"itself", 0, 0, 0, 0, nil},
+		{"direct", 0, 1, 2, 1, []int{1}},
+		{"equal distance, more hops", 0, 2, 4, 1, []int{1, 2}},
+		{"shorter through others", 0, 3, 5, 1, []int{1, 2, 3}},
+		{"shorter through one", 1, 3, 3, 2, []int{2, 3}},
+		{"backwards", 3, 0, 5, 2, []int{2, 1, 0}},
+		{"unreachable", 0, 4, unreachable, invalidPath, nil},
+	}
+	for _, tt := range tests {
+		if got := p.Dist(tt.src, tt.dst); got != tt.dist {
+			t.Errorf("%s: Dist(%d, %d) = %d, want %d", tt.name, tt.src, tt.dst, got, tt.dist)
+		}
+		if got := p.Next(tt.src, tt.dst); got != tt.next {
+			t.Errorf("%s: Next(%d, %d) = %d, want %d", tt.name, tt.src, tt.dst, got, tt.next)
+		}
+		if got := p.Path(tt.src, tt.dst); !reflect.DeepEqual(got, tt.path) {
+			t.Errorf("%s: Path(%d, %d) = %v, want %v", tt.name, tt.src, tt.dst, got, tt.path)
+		}
+	}
+
+	closest := []struct {
+		src  int
+		want []int
+	}{
+		{0, []int{0, 1, 2, 3, 4}},
+		{1, []int{1, 0, 2, 3, 4}}, // ties keep the factory order
+		{2, []int{2, 3, 1, 0, 4}},
+		{4, []int{4, 0, 1, 2, 3}},
+	}
+	for _, tt := range closest {
+		if got := p.Closest(tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Closest(%d) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const gameTurns = 200
+
+// threatHorizon returns the number of turns before the cyborgs spent on an
+// INC are missed: opponent troops already heading to the factory, or an
+// opponent factory able to take it after the INC but not before.
+func (g *Game) threatHorizon(f *factory) int {
+	horizon := gameTurns - g.Turn
+	for _, t := range g.Troops {
+		if t.Faction == opponentFaction && t.Dst == f.ID && t.Turns < horizon {
+			horizon = t.Turns
+		}
+	}
+	for _, o := range g.OpponentF {
+		d := g.Board[o.ID][f.ID] + 1
+		attack := o.Cyborg + o.Prod*d
+		garrison := f.Cyborg + f.Prod*d
+		if d < horizon && attack > garrison-incCost && attack <= garrison {
+			horizon = d
+		}
+	}
+	return horizon
+}
+
+// payback returns the number of turns before the extra production of an INC
+// gives back its cost, starting in delay turns.
+func payback(f *factory, delay int) int {
+	return delay + f.Disabled + incCost
+}
+
+// worthIncrease reports whether an INC on the factory pays back before the
+// threat horizon, without eating the garrison it needs. Capturing a neutral
+// factory which pays back sooner comes first.
+func (g *Game) worthIncrease(f *factory) bool {
+	if f.Prod >= maxProd || g.surplus(f) < incCost {
+		return false
+	}
+	pb := payback(f, 0)
+	for _, n := range g.NeutralF {
+		if n.Prod > 0 && !g.attacked(n) && g.Board[f.ID][n.ID]+(n.Cyborg+n.Prod)/n.Prod < pb {
+			return false
+		}
+	}
+	return pb <= g.threatHorizon(f)
+}
+
+// increase spends cyborgs on INC where it pays back, and brings cyborgs to
+// the zero production factories lacking them to upgrade.
+func (g *Game) increase(available map[int]int) {
+	for _, f := range g.PlayerF {
+		if !g.worthIncrease(f) {
+			continue
+		}
+		g.Order(order{Kind: orderInc, Src: f.ID})
+		f.Cyborg -= incCost
+		available[f.ID] -= incCost
+	}
+
+	for _, f := range g.PlayerF {
+		missing := incCost - f.Cyborg - f.Troops.Player
+		if f.Prod > 0 || missing <= 0 || g.attacked(f) {
+			continue
+		}
+		for _, id := range g.Paths.Closest(f.ID) {
+			src := g.Factories[id]
+			if id == f.ID || src.Faction != playerFaction || available[id] < missing {
+				continue
+			}
+			if payback(f, g.Board[id][f.ID]+1) > g.threatHorizon(f) {
+				break
+			}
+			fmt.Fprintln(os.Stderr, "upgrade:", f, "from:", src)
+			g.Order(order{Kind: orderMove, Src: id, Dst: f.ID, Cyborg: missing})
+			src.Cyborg -= missing
+			available[id] -= missing
+			break
+		}
+	}
+}
+
+// worthCapture reports whether a neutral factory without production is
+// worth taking to upgrade it: capture and INC have to pay back before the
+// threat horizon. The INC starts once the troops of the closest player
+// factory land, and the single cyborg of production it brings then has to
+// pay back the defenders killed as well.
+func (g *Game) worthCapture(f *factory) bool {
+	if f.Prod > 0 {
+		return true
+	}
+	dist := -1
+	for _, p := range g.PlayerF {
+		if dist < 0 || g.Board[p.ID][f.ID] < dist {
+			dist = g.Board[p.ID][f.ID]
+		}
+	}
+	if dist < 0 {
+		return false
+	}
+	capture := f.Cyborg + 1
+	return payback(f, dist+1)+capture <= g.threatHorizon(f)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+const (
+	searchBudget = 40 * time.Millisecond
+	searchDepth  = 10 // turns simulated to score an order set
+	prodWeight   = 10 // score of one cyborg of production
+	mutateRate   = 0.3
+)
+
+// search is a Monte Carlo search over the joint orders of the player
+// factories. Every factory has a few options, the first one being the
+// orders given by the planners. The bomb chosen by planBomb is a decision
+// of its own, sent or held, so an order set holds at most one BOMB. Order
+// sets are scored by simulating the next turns against a modelled
+// opponent.
+type search struct {
+	g       *Game
+	rand    *rand.Rand
+	ids     []int       // player factories, invalidPath for the bomb
+	options [][][]order // options of every factory
+	msgs    []order
+}
+
+func newSearch(g *Game) *search {
+	s := &search{
+		g:    g,
+		rand: rand.New(rand.NewSource(int64(g.Turn))),
+	}
+	planned := make(map[int][]order)
+	var bombs []order
+	for _, o := range g.Orders {
+		switch o.Kind {
+		case orderMsg:
+			s.msgs = append(s.msgs, o)
+		case orderBomb:
+			bombs = append(bombs, o)
+		default:
+			planned[o.Src] = append(planned[o.Src], o)
+		}
+	}
+	for _, f := range g.PlayerF {
+		s.ids = append(s.ids, f.ID)
+		s.options = append(s.options, s.factoryOptions(f, planned))
+	}
+	if len(bombs) > 0 {
+		s.ids = append(s.ids, invalidPath)
+		s.options = append(s.options, [][]order{bombs, nil})
+	}
+	return s
+}
+
+// factoryOptions returns the planned orders of the factory followed by the
+// alternatives: doing nothing, INC or sending its surplus to one of the
+// closest factories.
+func (s *search) factoryOptions(f *factory, planned map[int][]order) [][]order {
+	g := s.g
+	options := [][]order{planned[f.ID], nil}
+
+	// Surplus of the factory when only the other factories give orders.
+	orders := g.Orders
+	g.Orders = nil
+	for src, o := range planned {
+		if src != f.ID {
+			g.Orders = append(g.Orders, o...)
+		}
+	}
+	surplus := g.timeline(f).Surplus()
+	g.Orders = orders
+
+	sf := g.Sim.Factories[f.ID]
+	if sf.Cyborg >= incCost && sf.Prod < maxProd {
+		options = append(options, []order{{Kind: orderInc, Src: f.ID}})
+	}
+	if surplus > 0 {
+		count := 0
+		for _, id := range g.Paths.Closest(f.ID) {
+			if id == f.ID || count >= closestNeighboor {
+				continue
+			}
+			count++
+			options = append(options,
+				[]order{{Kind: orderMove, Src: f.ID, Dst: id, Cyborg: surplus}},
+				[]order{{Kind: orderMove, Src: f.ID, Dst: id, Cyborg: (surplus + 1) / 2}})
+		}
+	}
+	return options
+}
+
+// orders returns the order set matching the option chosen for every
+// factory.
+func (s *search) orders(choice []int) []order {
+	var orders []order
+	for i, c := range choice {
+		orders = append(orders, s.options[i][c]...)
+	}
+	return orders
+}
+
+// evaluate plays the order set then lets both factions follow the rollout
+// policy, and scores the final state.
+func (s *search) evaluate(orders []order) int {
+	sim := s.g.Sim.Clone()
+	sim.Apply(playerFaction, orders)
+	sim.Apply(opponentFaction, rollout(sim, opponentFaction))
+	sim.Step()
+	for i := 1; i < searchDepth; i++ {
+		sim.Apply(playerFaction, rollout(sim, playerFaction))
+		sim.Apply(opponentFaction, rollout(sim, opponentFaction))
+		sim.Step()
+	}
+	score := sim.Cyborgs(playerFaction) - sim.Cyborgs(opponentFaction)
+	for _, f := range sim.Factories {
+		if f.Faction == playerFaction {
+			score += f.Prod * prodWeight
+		} else if f.Faction == opponentFaction {
+			score -= f.Prod * prodWeight
+		}
+	}
+	return score
+}
+
+// rollout is the policy modelling a faction: every factory with more than
+// incCost cyborgs sends half of them to the closest factory it does not own.
+func rollout(s *simulation, faction int) []order {
+	var orders []order
+	for _, f := range s.Factories {
+		if f.Faction != faction || f.Cyborg <= incCost {
+			continue
+		}
+		dst := invalidPath
+		for id, other := range s.Factories {
+			if other.Faction != faction && (dst == invalidPath || s.Board[f.ID][id] < s.Board[f.ID][dst]) {
+				dst = id
+			}
+		}
+		if dst != invalidPath {
+			orders = append(orders, order{Kind: orderMove, Src: f.ID, Dst: dst, Cyborg: f.Cyborg / 2})
+		}
+	}
+	return orders
+}
+
+// Run mutates the best order set until the deadline and returns it. The
+// planned orders are kept unless an order set scores better.
+func (s *search) Run(deadline time.Time) []order {
+	best := make([]int, len(s.ids))
+	bestScore := s.evaluate(s.orders(best))
+	planned := bestScore
+	choice := make([]int, len(s.ids))
+	n := 0
+	for ; time.Now().Before(deadline); n++ {
+		for i := range choice {
+			choice[i] = best[i]
+			if s.rand.Float64() < mutateRate {
+				choice[i] = s.rand.Intn(len(s.options[i]))
+			}
+		}
+		if score := s.evaluate(s.orders(choice)); score > bestScore {
+			bestScore = score
+			copy(best, choice)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "search:", n, "sets, planned:", planned, "best:", bestScore)
+	return append(s.orders(best), s.msgs...)
+}
+
+// commit replaces the orders of this turn and updates the plans they
+// belong to: attacks missing a wave are dropped and a bomb held is given
+// back.
+func (g *Game) commit(orders []order) {
+	kept := make(map[order]bool, len(orders))
+	bomb := false
+	for _, o := range orders {
+		kept[o] = true
+		if o.Kind == orderBomb {
+			bomb = true
+		}
+	}
+	planned := false
+	for _, o := range g.Orders {
+		if o.Kind == orderBomb {
+			planned = true
+		}
+		if o.Kind != orderMove || kept[o] {
+			continue
+		}
+		attacks := g.Attacks[:0]
+		for _, a := range g.Attacks {
+			if a.Target != o.Dst {
+				attacks = append(attacks, a)
+			}
+		}
+		g.Attacks = attacks
+	}
+	if planned && !bomb {
+		g.Bomb.Count++
+		g.Bomb.Timer = 0
+	}
+	g.Orders = orders
+	g.updateForecast()
+}
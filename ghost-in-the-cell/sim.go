@@ -0,0 +1,254 @@
+package main
+
+import "fmt"
+
+const (
+	incCost       = 10
+	maxProd       = 3
+	bombMinDamage = 10
+	disabledTurns = 5
+	orderMove     = "MOVE"
+	orderBomb     = "BOMB"
+	orderInc      = "INC"
+	orderMsg      = "MSG"
+)
+
+type bomb struct {
+	ID      int
+	Faction int
+	Src     int
+	Dst     int // -1 for opponent bombs until the target is guessed
+	Turns   int // -1 for opponent bombs until the target is guessed
+	Seen    int // turn the bomb was first seen
+}
+
+func (b *bomb) String() string {
+	return fmt.Sprintf("{Src: %d, Dst: %d, Turns: %d}", b.Src, b.Dst, b.Turns)
+}
+
+type order struct {
+	Kind   string
+	Src    int
+	Dst    int
+	Cyborg int
+	Msg    string
+}
+
+func (o order) String() string {
+	switch o.Kind {
+	case orderMove:
+		return fmt.Sprintf("%s %d %d %d", o.Kind, o.Src, o.Dst, o.Cyborg)
+	case orderBomb:
+		return fmt.Sprintf("%s %d %d", o.Kind, o.Src, o.Dst)
+	case orderInc:
+		return fmt.Sprintf("%s %d", o.Kind, o.Src)
+	case orderMsg:
+		return fmt.Sprintf("%s %s", o.Kind, o.Msg)
+	}
+	return "WAIT"
+}
+
+// simulation replays the referee rules on a copy of the game. Every turn
+// the referee moves troops and bombs, executes the orders, produces
+// cyborgs, solves the battles and finally makes the bombs explode.
+//
+// Orders are applied before calling Step, so the troops and bombs they
+// create get one more turn to travel: after Step they are exactly in the
+// state the referee sends on the next turn.
+type simulation struct {
+	Board     [][]int
+	Factories []factory // indexed by ID
+	Troops    []troop
+	Bombs     []bomb
+	Turn      int
+}
+
+func newSimulation(g *Game) *simulation {
+	s := &simulation{
+		Board:     g.Board,
+		Factories: make([]factory, g.FactoryCount),
+		Troops:    make([]troop, 0, len(g.Troops)),
+		Turn:      g.Turn,
+	}
+	for id, f := range g.Factories {
+		s.Factories[id] = *f
+	}
+	for _, t := range g.Troops {
+		s.Troops = append(s.Troops, *t)
+	}
+	for _, b := range g.Bombs {
+		if b.Dst != invalidPath {
+			s.Bombs = append(s.Bombs, *b)
+		}
+	}
+	return s
+}
+
+// Clone returns a copy of the simulation, the board is shared.
+func (s *simulation) Clone() *simulation {
+	return &simulation{
+		Board:     s.Board,
+		Factories: append([]factory(nil), s.Factories...),
+		Troops:    append([]troop(nil), s.Troops...),
+		Bombs:     append([]bomb(nil), s.Bombs...),
+		Turn:      s.Turn,
+	}
+}
+
+// Apply executes the orders of one faction. Invalid orders are ignored like
+// the referee does.
+func (s *simulation) Apply(faction int, orders []order) {
+	for _, o := range orders {
+		if o.Src < 0 || o.Src >= len(s.Factories) {
+			continue
+		}
+		src := &s.Factories[o.Src]
+		if src.Faction != faction {
+			continue
+		}
+		switch o.Kind {
+		case orderMove:
+			cyborg := o.Cyborg
+			if cyborg > src.Cyborg {
+				cyborg = src.Cyborg
+			}
+			if o.Dst == o.Src || cyborg <= 0 {
+				continue
+			}
+			src.Cyborg -= cyborg
+			s.Troops = append(s.Troops, troop{
+				Faction: faction,
+				Src:     o.Src,
+				Dst:     o.Dst,
+				Cyborg:  cyborg,
+				Turns:   s.Board[o.Src][o.Dst] + 1,
+			})
+		case orderBomb:
+			if o.Dst == o.Src {
+				continue
+			}
+			s.Bombs = append(s.Bombs, bomb{
+				Faction: faction,
+				Src:     o.Src,
+				Dst:     o.Dst,
+				Turns:   s.Board[o.Src][o.Dst] + 1,
+			})
+		case orderInc:
+			if src.Cyborg < incCost || src.Prod >= maxProd {
+				continue
+			}
+			src.Cyborg -= incCost
+			src.Prod++
+		}
+	}
+}
+
+// Step plays the end of the turn, once the orders are applied.
+func (s *simulation) Step() {
+	for i := range s.Troops {
+		s.Troops[i].Turns--
+	}
+	for i := range s.Bombs {
+		s.Bombs[i].Turns--
+	}
+
+	for i := range s.Factories {
+		f := &s.Factories[i]
+		if f.Disabled > 0 {
+			f.Disabled--
+			continue
+		}
+		if f.Faction != neutralFaction {
+			f.Cyborg += f.Prod
+		}
+	}
+
+	// Troops arriving on the same factory fight each other first, the
+	// survivors then fight the garrison.
+	arriving := make([]struct{ Player, Opponent int }, len(s.Factories))
+	troops := s.Troops[:0]
+	for _, t := range s.Troops {
+		if t.Turns > 0 {
+			troops = append(troops, t)
+			continue
+		}
+		if t.Faction == playerFaction {
+			arriving[t.Dst].Player += t.Cyborg
+		} else {
+			arriving[t.Dst].Opponent += t.Cyborg
+		}
+	}
+	s.Troops = troops
+	for i, a := range arriving {
+		cyborg, faction := a.Player-a.Opponent, playerFaction
+		if cyborg < 0 {
+			cyborg, faction = -cyborg, opponentFaction
+		}
+		if cyborg == 0 {
+			continue
+		}
+		f := &s.Factories[i]
+		if f.Faction == faction {
+			f.Cyborg += cyborg
+			continue
+		}
+		f.Cyborg -= cyborg
+		if f.Cyborg < 0 {
+			f.Cyborg, f.Faction = -f.Cyborg, faction
+		}
+	}
+
+	bombs := s.Bombs[:0]
+	for _, b := range s.Bombs {
+		if b.Turns > 0 {
+			bombs = append(bombs, b)
+			continue
+		}
+		f := &s.Factories[b.Dst]
+		f.Cyborg -= bombDamage(f.Cyborg)
+		f.Disabled = disabledTurns
+	}
+	s.Bombs = bombs
+	s.Turn++
+}
+
+// Run plays turns without giving any order.
+func (s *simulation) Run(turns int) {
+	for i := 0; i < turns; i++ {
+		s.Step()
+	}
+}
+
+// Horizon returns the number of turns before every troop and bomb in
+// flight has landed.
+func (s *simulation) Horizon() int {
+	turns := 0
+	for _, t := range s.Troops {
+		if t.Turns > turns {
+			turns = t.Turns
+		}
+	}
+	for _, b := range s.Bombs {
+		if b.Turns > turns {
+			turns = b.Turns
+		}
+	}
+	return turns
+}
+
+// Cyborgs returns the number of cyborgs owned by a faction, in factories and
+// in troops.
+func (s *simulation) Cyborgs(faction int) int {
+	count := 0
+	for _, f := range s.Factories {
+		if f.Faction == faction {
+			count += f.Cyborg
+		}
+	}
+	for _, t := range s.Troops {
+		if t.Faction == faction {
+			count += t.Cyborg
+		}
+	}
+	return count
+}
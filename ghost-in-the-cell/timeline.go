@@ -0,0 +1,91 @@
+package main
+
+import "fmt"
+
+// timeline is the forecast of a factory for the next turns, as if it kept
+// its current owner and sent nothing more.
+type timeline struct {
+	Arrivals []int // net cyborgs landing every turn, owner troops positive
+	Garrison []int // garrison at the end of every turn, index 0 is now
+}
+
+func (t timeline) String() string {
+	return fmt.Sprintf("{Arrivals: %v, Garrison: %v}", t.Arrivals, t.Garrison)
+}
+
+// timeline returns the timeline of the factory over maxDistance turns,
+// once the orders of this turn are executed.
+func (g *Game) timeline(f *factory) timeline {
+	s := g.Sim.Clone()
+	s.Apply(playerFaction, g.Orders)
+	sf := s.Factories[f.ID]
+
+	t := timeline{
+		Arrivals: make([]int, maxDistance+1),
+		Garrison: make([]int, maxDistance+1),
+	}
+	for _, tr := range s.Troops {
+		if tr.Dst != f.ID || tr.Turns > maxDistance {
+			continue
+		}
+		if tr.Faction == sf.Faction {
+			t.Arrivals[tr.Turns] += tr.Cyborg
+		} else {
+			t.Arrivals[tr.Turns] -= tr.Cyborg
+		}
+	}
+	bombs := make([]bool, maxDistance+1)
+	for _, b := range s.Bombs {
+		if b.Dst == f.ID && b.Faction != sf.Faction && b.Turns <= maxDistance {
+			bombs[b.Turns] = true
+		}
+	}
+
+	garrison, disabled := sf.Cyborg, sf.Disabled
+	t.Garrison[0] = garrison
+	for turn := 1; turn <= maxDistance; turn++ {
+		if disabled > 0 {
+			disabled--
+		} else if sf.Faction != neutralFaction {
+			garrison += sf.Prod
+		}
+		garrison += t.Arrivals[turn]
+		if bombs[turn] {
+			if garrison > 0 {
+				garrison -= bombDamage(garrison)
+			}
+			disabled = disabledTurns
+		}
+		t.Garrison[turn] = garrison
+	}
+	return t
+}
+
+// Surplus returns the cyborgs which can leave the factory now without
+// losing it later on: every cyborg sent lowers the garrison of every turn.
+func (t timeline) Surplus() int {
+	surplus := t.Garrison[0]
+	for _, garrison := range t.Garrison {
+		if garrison < surplus {
+			surplus = garrison
+		}
+	}
+	if surplus < 0 {
+		surplus = 0
+	}
+	return surplus
+}
+
+// Deficit returns the cyborgs missing to keep the factory, and the first
+// turn it falls. The deficit is 0 when the factory holds.
+func (t timeline) Deficit() (cyborg, turn int) {
+	for i, garrison := range t.Garrison {
+		if -garrison > cyborg {
+			cyborg = -garrison
+		}
+		if garrison < 0 && turn == 0 {
+			turn = i
+		}
+	}
+	return cyborg, turn
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTimeline(t *testing.T) {
+	board := [][]int{
+		{0, 3, 6},
+		{3, 0, 4},
+		{6, 4, 0},
+	}
+	factories := []factory{
+		{ID: 0, Faction: playerFaction, Cyborg: 10, Prod: 2},
+		{ID: 1, Faction: playerFaction, Cyborg: 10, Prod: 1},
+		{ID: 2, Faction: opponentFaction, Cyborg: 10, Prod: 3},
+	}
+	friendly := troop{ID: 3, Faction: playerFaction, Src: 1, Dst: 0, Cyborg: 4, Turns: 2}
+	opponentBomb := &bomb{ID: 5, Faction: opponentFaction, Src: 2, Dst: 0, Turns: 1}
+
+	tests := []struct {
+		name     string
+		troops   []troop
+		bomb     bool
+		garrison []int // first turns of the timeline
+		surplus  int
+		deficit  int
+		fall     int
+	}{
+		{
+			// Used to panic when looking for the closest opponent troop.
+			name:     "no troop",
+			garrison: []int{10, 12, 14, 16, 18, 20, 22, 24},
+			surplus:  10,
+		},
+		{
+			// The bomb lands first: 12 cyborgs lose 10 and the production
+			// stops for 5 turns.
+			name: "holds",
+			troops: []troop{friendly,
+				{ID: 4, Faction: opponentFaction, Src: 2, Dst: 0, Cyborg: 5, Turns: 3}},
+			bomb:     true,
+			garrison: []int{10, 2, 6, 1, 1, 1, 1, 3},
+			surplus:  1,
+		},
+		{
+			name: "falls",
+			troops: []troop{friendly,
+				{ID: 4, Faction: opponentFaction, Src: 2, Dst: 0, Cyborg: 25, Turns: 3}},
+			bomb:     true,
+			garrison: []int{10, 2, 6, -19, -19, -19, -19, -17},
+			deficit:  19,
+			fall:     3,
+		},
+	}
+	for _, tt := range tests {
+		g := newTestGame(board, factories, tt.troops)
+		if tt.bomb {
+			g.Bombs[opponentBomb.ID] = opponentBomb
+			g.Sim = newSimulation(g)
+		}
+		tl := g.timeline(g.Factories[0])
+		if got := tl.Garrison[:len(tt.garrison)]; !reflect.DeepEqual(got, tt.garrison) {
+			t.Errorf("%s: Garrison = %v, want %v", tt.name, got, tt.garrison)
+		}
+		if got := tl.Surplus(); got != tt.surplus {
+			t.Errorf("%s: Surplus() = %d, want %d", tt.name, got, tt.surplus)
+		}
+		if got := g.surplus(g.Factories[0]); got != tt.surplus {
+			t.Errorf("%s: surplus() = %d, want %d", tt.name, got, tt.surplus)
+		}
+		if deficit, fall := tl.Deficit(); deficit != tt.deficit || fall != tt.fall {
+			t.Errorf("%s: Deficit() = %d, %d, want %d, %d", tt.name, deficit, fall, tt.deficit, tt.fall)
+		}
+	}
+}
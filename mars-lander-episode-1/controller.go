@@ -0,0 +1,45 @@
+package main
+
+// VerticalController is a bang-bang controller with lookahead: it keeps
+// the thrust as low as possible as long as switching to full power later
+// still brings the lander down slower than MaxVSpeed.
+type VerticalController struct {
+	MaxVSpeed float64 // highest vertical speed allowed at touchdown
+	Margin    float64 // safety margin kept below MaxVSpeed
+	Lookahead int     // maximum number of seconds simulated
+}
+
+func NewVerticalController() *VerticalController {
+	return &VerticalController{
+		MaxVSpeed: landingVSpeed,
+		Margin:    2,
+		Lookahead: 200,
+	}
+}
+
+// Power returns the lowest thrust for which the lander still touches the
+// ground safely, given it goes full power from the next second on.
+func (c *VerticalController) Power(l Lander, s *Surface) int {
+	ground := l.Y - s.Altitude(l.X, l.Y)
+	for p := 0; p < maxPower; p++ {
+		if c.safe(l, ground, p) {
+			return p
+		}
+	}
+	return maxPower
+}
+
+// safe reports whether the lander touches the ground slower than the limit.
+// Once the lander is under the limit it can hold its speed, provided there
+// is enough fuel left to do so until touchdown.
+func (c *VerticalController) safe(l Lander, ground float64, power int) bool {
+	limit := c.MaxVSpeed - c.Margin
+	l.Step(0, power)
+	for i := 0; i < c.Lookahead && l.Y > ground; i++ {
+		if -l.VSpeed <= limit && float64(l.Fuel) >= maxPower*(l.Y-ground)/limit {
+			return true
+		}
+		l.Step(0, maxPower)
+	}
+	return -l.VSpeed <= limit
+}
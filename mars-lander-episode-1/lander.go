@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	marsGravity = 3.711
+	maxRotate   = 90
+	maxPower    = 4
+	rotateStep  = 15
+	powerStep   = 1
+
+	// Highest speeds allowed at touchdown.
+	landingHSpeed = 20
+	landingVSpeed = 40
+)
+
+// Lander holds the state of the capsule as sent by the referee every turn.
+// Positions and speeds are kept as float to avoid accumulating rounding
+// errors when simulating several seconds ahead.
+type Lander struct {
+	X, Y           float64
+	HSpeed, VSpeed float64
+	Fuel           int
+	Rotate         int
+	Power          int
+}
+
+func (l Lander) String() string {
+	return fmt.Sprintf("{X: %.0f, Y: %.0f, HS: %.0f, VS: %.0f, F: %d, R: %d, P: %d}",
+		l.X, l.Y, l.HSpeed, l.VSpeed, l.Fuel, l.Rotate, l.Power)
+}
+
+// Step advances the lander by one second with the requested rotate and
+// power. Like the referee, the command is clamped to ±15° and ±1 from the
+// current values, and the power is limited by the remaining fuel.
+func (l *Lander) Step(rotate, power int) {
+	l.Rotate = clamp(rotate, l.Rotate-rotateStep, l.Rotate+rotateStep)
+	l.Rotate = clamp(l.Rotate, -maxRotate, maxRotate)
+	l.Power = clamp(power, l.Power-powerStep, l.Power+powerStep)
+	l.Power = clamp(l.Power, 0, maxPower)
+	if l.Power > l.Fuel {
+		l.Power = l.Fuel
+	}
+	l.Fuel -= l.Power
+
+	rad := float64(l.Rotate) * math.Pi / 180
+	ax := -float64(l.Power) * math.Sin(rad)
+	ay := float64(l.Power)*math.Cos(rad) - marsGravity
+	l.X += l.HSpeed + ax/2
+	l.Y += l.VSpeed + ay/2
+	l.HSpeed += ax
+	l.VSpeed += ay
+}
+
+// Touchdown holds the command until the lander reaches ground altitude and
+// returns its state at that point. It gives up after maxTurns seconds.
+func (l Lander) Touchdown(ground float64, rotate, power, maxTurns int) Lander {
+	for i := 0; i < maxTurns && l.Y > ground; i++ {
+		l.Step(rotate, power)
+	}
+	return l
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
@@ -1,8 +1,8 @@
 package main
 
+import "flag"
 import "fmt"
-
-//import "os"
+import "os"
 
 /**
  * Auto-generated code below aims at helping you parse
@@ -10,16 +10,33 @@ import "fmt"
  **/
 
 func main() {
+	runner := flag.Bool("runner", false, "play the test maps locally instead of reading the referee")
+	bot := flag.String("bot", "", "bot binary played by the runner (default: this binary)")
+	flag.Parse()
+	if *runner {
+		runnerMain(*bot)
+		return
+	}
+
 	// surfaceN: the number of points used to draw the surface of Mars.
 	var surfaceN int
 	fmt.Scan(&surfaceN)
 
+	points := make([]Point, surfaceN)
 	for i := 0; i < surfaceN; i++ {
 		// landX: X coordinate of a surface point. (0 to 6999)
 		// landY: Y coordinate of a surface point. By linking all the points together in a sequential fashion, you form the surface of Mars.
 		var landX, landY int
 		fmt.Scan(&landX, &landY)
+		points[i] = Point{X: float64(landX), Y: float64(landY)}
 	}
+	surface, err := NewSurface(points)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	fmt.Fprintln(os.Stderr, "flat:", surface.Flat)
+
+	var strategy Strategy
 	for {
 		// hSpeed: the horizontal speed (in m/s), can be negative.
 		// vSpeed: the vertical speed (in m/s), can be negative.
@@ -28,10 +45,21 @@ func main() {
 		// power: the thrust power (0 to 4).
 		var X, Y, hSpeed, vSpeed, fuel, rotate, power int
 		fmt.Scan(&X, &Y, &hSpeed, &vSpeed, &fuel, &rotate, &power)
-
-		// fmt.Fprintln(os.Stderr, "Debug messages...")
+		l := Lander{
+			X:      float64(X),
+			Y:      float64(Y),
+			HSpeed: float64(hSpeed),
+			VSpeed: float64(vSpeed),
+			Fuel:   fuel,
+			Rotate: rotate,
+			Power:  power,
+		}
+		fmt.Fprintln(os.Stderr, l)
 
 		// 2 integers: rotate power. rotate is the desired rotation angle (should be 0 for level 1), power is the desired thrust power (0 to 4).
-		fmt.Println("0 3")
+		if strategy == nil {
+			strategy = selectStrategy(surface, l)
+		}
+		fmt.Println(strategy.Command(l))
 	}
 }
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	mapWidth  = 7000
+	mapHeight = 3000
+	maxTurns  = 300
+)
+
+// gene is a change of the rotate and power command for one second.
+type gene struct {
+	Rotate, Power int
+}
+
+type chromosome struct {
+	Genes []gene
+	Score float64
+}
+
+// Planner is the episode 3 strategy: a rolling horizon evolutionary
+// algorithm evolving the sequence of commands of the next seconds. The
+// population is kept between turns and shifted by one second each time.
+type Planner struct {
+	Surface *Surface
+
+	Budget     time.Duration // time allowed to evolve every turn
+	Population int           // number of chromosomes
+	Length     int           // number of genes, the last command is held after
+	Elite      int           // best chromosomes kept as is
+	Mutation   float64       // probability of a gene to be randomized
+	Margin     float64       // safety margin kept below the landing speeds
+
+	rand *rand.Rand
+	pop  []chromosome
+}
+
+func NewPlanner(s *Surface) *Planner {
+	return &Planner{
+		Surface:    s,
+		Budget:     80 * time.Millisecond,
+		Population: 40,
+		Length:     80,
+		Elite:      4,
+		Mutation:   0.05,
+		Margin:     2,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (p *Planner) Command(l Lander) (rotate, power int) {
+	deadline := time.Now().Add(p.Budget)
+	if p.pop == nil {
+		p.pop = make([]chromosome, p.Population)
+		for i := range p.pop {
+			p.pop[i].Genes = make([]gene, p.Length)
+			for j := range p.pop[i].Genes {
+				p.pop[i].Genes[j] = p.randomGene()
+			}
+		}
+	} else {
+		for i := range p.pop {
+			genes := p.pop[i].Genes
+			copy(genes, genes[1:])
+			genes[len(genes)-1] = p.randomGene()
+		}
+	}
+	for i := range p.pop {
+		p.pop[i].Score = p.evaluate(l, p.pop[i].Genes)
+	}
+	p.sort()
+
+	next := make([]chromosome, len(p.pop))
+	for i := range next {
+		next[i].Genes = make([]gene, p.Length)
+	}
+	for time.Now().Before(deadline) {
+		for i := range next {
+			if i < p.Elite {
+				copy(next[i].Genes, p.pop[i].Genes)
+				next[i].Score = p.pop[i].Score
+				continue
+			}
+			p.crossover(next[i].Genes, p.selectParent().Genes, p.selectParent().Genes)
+			p.mutate(next[i].Genes)
+			next[i].Score = p.evaluate(l, next[i].Genes)
+		}
+		p.pop, next = next, p.pop
+		p.sort()
+	}
+
+	// The genes are deltas, the referee only accepts absolute values within
+	// its ranges.
+	g := p.pop[0].Genes[0]
+	return clamp(l.Rotate+g.Rotate, -maxRotate, maxRotate), clamp(l.Power+g.Power, 0, maxPower)
+}
+
+func (p *Planner) randomGene() gene {
+	return gene{
+		Rotate: p.rand.Intn(2*rotateStep+1) - rotateStep,
+		Power:  p.rand.Intn(2*powerStep+1) - powerStep,
+	}
+}
+
+func (p *Planner) sort() {
+	pop := p.pop
+	for i := 1; i < len(pop); i++ {
+		for j := i; j > 0 && pop[j].Score > pop[j-1].Score; j-- {
+			pop[j], pop[j-1] = pop[j-1], pop[j]
+		}
+	}
+}
+
+// selectParent runs a tournament between two random chromosomes.
+func (p *Planner) selectParent() *chromosome {
+	a, b := &p.pop[p.rand.Intn(len(p.pop))], &p.pop[p.rand.Intn(len(p.pop))]
+	if a.Score > b.Score {
+		return a
+	}
+	return b
+}
+
+// crossover blends both parents with a random weight.
+func (p *Planner) crossover(dst, a, b []gene) {
+	w := p.rand.Float64()
+	for i := range dst {
+		dst[i].Rotate = int(math.Round(w*float64(a[i].Rotate) + (1-w)*float64(b[i].Rotate)))
+		dst[i].Power = int(math.Round(w*float64(a[i].Power) + (1-w)*float64(b[i].Power)))
+	}
+}
+
+func (p *Planner) mutate(genes []gene) {
+	for i := range genes {
+		if p.rand.Float64() < p.Mutation {
+			genes[i] = p.randomGene()
+		}
+	}
+}
+
+// evaluate plays the genes and scores the end of the flight: landing is
+// worth more than any crash, crashing on the landing zone is worth more
+// than crashing elsewhere, and closer crashes are worth more.
+func (p *Planner) evaluate(l Lander, genes []gene) float64 {
+	s := p.Surface
+	var g gene
+	for i := 0; i < maxTurns; i++ {
+		if i < len(genes) {
+			g = genes[i]
+		}
+		prev := Point{X: l.X, Y: l.Y}
+		l.Step(l.Rotate+g.Rotate, l.Power+g.Power)
+		if l.X < 0 || l.X >= mapWidth || l.Y < 0 || l.Y >= mapHeight {
+			return 0
+		}
+		cur := Point{X: l.X, Y: l.Y}
+		seg := s.Hit(prev, cur)
+		if seg < 0 {
+			continue
+		}
+		if seg != s.flat {
+			return 100 * (1 - s.DistanceToFlat(seg, l.X)/s.Length())
+		}
+		hs := math.Max(0, math.Abs(l.HSpeed)-landingHSpeed+p.Margin)
+		vs := math.Max(0, -l.VSpeed-landingVSpeed+p.Margin)
+		if hs == 0 && vs == 0 && l.Rotate == 0 {
+			return 300 + float64(l.Fuel)
+		}
+		return 200 - hs - vs - math.Abs(float64(l.Rotate))/10
+	}
+	return 0
+}
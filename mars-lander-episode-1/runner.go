@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+)
+
+type testMap struct {
+	Name    string
+	Surface []Point
+	Lander  Lander
+}
+
+// testMaps are the test cases of the three episodes.
+var testMaps = []testMap{
+	{
+		Name:    "episode 1: straight landing",
+		Surface: []Point{{0, 100}, {1000, 500}, {1500, 100}, {3000, 100}, {5000, 1500}, {6999, 1000}},
+		Lander:  Lander{X: 2500, Y: 2500, Fuel: 500},
+	},
+	{
+		Name:    "episode 2: easy on the right",
+		Surface: []Point{{0, 100}, {1000, 500}, {1500, 1500}, {3000, 1000}, {4000, 150}, {5500, 150}, {6999, 800}},
+		Lander:  Lander{X: 2500, Y: 2700, Fuel: 550},
+	},
+	{
+		Name:    "episode 2: initial speed, correct side",
+		Surface: []Point{{0, 100}, {1000, 500}, {1500, 100}, {3000, 100}, {3500, 500}, {3700, 200}, {5000, 1500}, {5800, 300}, {6000, 1000}, {6999, 2000}},
+		Lander:  Lander{X: 6500, Y: 2800, HSpeed: -100, Fuel: 600, Rotate: 90},
+	},
+	{
+		Name:    "episode 2: initial speed, wrong side",
+		Surface: []Point{{0, 100}, {1000, 500}, {1500, 1500}, {3000, 1000}, {4000, 150}, {5500, 150}, {6999, 800}},
+		Lander:  Lander{X: 6500, Y: 2800, HSpeed: -90, Fuel: 750, Rotate: 90},
+	},
+	{
+		Name:    "episode 2: deep canyon",
+		Surface: []Point{{0, 1000}, {300, 1500}, {350, 1400}, {500, 2000}, {800, 1800}, {1000, 2500}, {1200, 2100}, {1500, 2400}, {2000, 1000}, {2200, 500}, {2500, 100}, {2900, 800}, {3000, 500}, {3200, 1000}, {3500, 2000}, {3800, 800}, {4000, 200}, {5000, 200}, {5500, 1500}, {6999, 2800}},
+		Lander:  Lander{X: 500, Y: 2700, HSpeed: 100, Fuel: 800, Rotate: -90},
+	},
+	{
+		Name:    "episode 2: high ground",
+		Surface: []Point{{0, 1000}, {300, 1500}, {350, 1400}, {500, 2100}, {1500, 2100}, {2000, 200}, {2500, 500}, {2900, 300}, {3000, 200}, {3200, 1000}, {3500, 500}, {3800, 800}, {4000, 200}, {4200, 800}, {4800, 600}, {5000, 1200}, {5500, 900}, {6000, 500}, {6500, 300}, {6999, 500}},
+		Lander:  Lander{X: 6500, Y: 2700, HSpeed: -50, Fuel: 1000, Rotate: 90},
+	},
+	{
+		Name:    "episode 3: cave, correct side",
+		Surface: []Point{{0, 1800}, {300, 1200}, {1000, 1550}, {2000, 1200}, {2500, 1650}, {3700, 220}, {4700, 220}, {4750, 1000}, {4700, 1650}, {4000, 1700}, {3700, 1600}, {3750, 1900}, {4000, 2100}, {4900, 2050}, {5100, 1000}, {5500, 500}, {6200, 800}, {6999, 600}},
+		Lander:  Lander{X: 6500, Y: 2000, Fuel: 1200},
+	},
+	{
+		Name:    "episode 3: cave, wrong side",
+		Surface: []Point{{0, 450}, {300, 750}, {1000, 450}, {1500, 650}, {1800, 850}, {2000, 1950}, {2200, 1850}, {2400, 2000}, {3100, 1800}, {3150, 1550}, {2500, 1600}, {2200, 1550}, {2100, 750}, {2200, 150}, {3200, 150}, {3500, 450}, {4000, 950}, {4500, 1450}, {5000, 1550}, {5500, 1500}, {6000, 950}, {6999, 1750}},
+		Lander:  Lander{X: 6500, Y: 2600, HSpeed: -20, Fuel: 1000, Rotate: 45},
+	},
+}
+
+type result struct {
+	Landed bool
+	Reason string
+	Lander Lander
+}
+
+func (r result) String() string {
+	status := "landed"
+	if !r.Landed {
+		status = "crashed (" + r.Reason + ")"
+	}
+	return fmt.Sprintf("%s, fuel: %d, hSpeed: %.0f, vSpeed: %.0f",
+		status, r.Lander.Fuel, r.Lander.HSpeed, r.Lander.VSpeed)
+}
+
+// runTests plays every test map with the bot binary and prints the results.
+// It returns false when the bot fails to land on any of them.
+func runTests(bot string) bool {
+	ok := true
+	for _, m := range testMaps {
+		r, err := run(bot, m)
+		if err != nil {
+			fmt.Printf("%-40s error: %v\n", m.Name, err)
+			ok = false
+			continue
+		}
+		fmt.Printf("%-40s %v\n", m.Name, r)
+		ok = ok && r.Landed
+	}
+	return ok
+}
+
+// run feeds the map to the bot and applies the rules to its commands until
+// the lander touches the ground or leaves the map.
+func run(bot string, m testMap) (result, error) {
+	s, err := NewSurface(m.Surface)
+	if err != nil {
+		return result{}, err
+	}
+	cmd := exec.Command(bot)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return result{}, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return result{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return result{}, err
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	out := bufio.NewReader(stdout)
+	fmt.Fprintln(stdin, len(s.Points))
+	for _, p := range s.Points {
+		fmt.Fprintln(stdin, p.X, p.Y)
+	}
+	l := m.Lander
+	for turn := 0; turn < maxTurns; turn++ {
+		fmt.Fprintln(stdin, math.Round(l.X), math.Round(l.Y), math.Round(l.HSpeed), math.Round(l.VSpeed),
+			l.Fuel, l.Rotate, l.Power)
+		var rotate, power int
+		if _, err := fmt.Fscanln(out, &rotate, &power); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return result{}, err
+		}
+		if rotate < -maxRotate || rotate > maxRotate || power < 0 || power > maxPower {
+			return result{Reason: fmt.Sprintf("invalid command %d %d", rotate, power), Lander: l}, nil
+		}
+
+		prev := Point{X: l.X, Y: l.Y}
+		l.Step(rotate, power)
+		if l.X < 0 || l.X >= mapWidth || l.Y < 0 || l.Y >= mapHeight {
+			return result{Reason: "out of map", Lander: l}, nil
+		}
+		seg := s.Hit(prev, Point{X: l.X, Y: l.Y})
+		switch {
+		case seg < 0:
+			continue
+		case seg != s.flat:
+			return result{Reason: "outside the landing zone", Lander: l}, nil
+		case l.Rotate != 0:
+			return result{Reason: "not upright", Lander: l}, nil
+		case math.Abs(l.HSpeed) > landingHSpeed || math.Abs(l.VSpeed) > landingVSpeed:
+			return result{Reason: "too fast", Lander: l}, nil
+		}
+		return result{Landed: true, Lander: l}, nil
+	}
+	return result{Reason: "out of time", Lander: l}, nil
+}
+
+func runnerMain(bot string) {
+	if bot == "" {
+		bot = os.Args[0]
+	}
+	if !runTests(bot) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildBot compiles the sources of the package, tests excluded, into a
+// temporary binary.
+func buildBot(t *testing.T) string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot := filepath.Join(t.TempDir(), "bot")
+	args := []string{"build", "-o", bot}
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			args = append(args, f)
+		}
+	}
+	if out, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+		t.Fatalf("build: %v\n%s", err, out)
+	}
+	return bot
+}
+
+func TestRunner(t *testing.T) {
+	if testing.Short() {
+		t.Skip("plays every test map in real time")
+	}
+	bot := buildBot(t)
+	for _, m := range testMaps {
+		t.Run(m.Name, func(t *testing.T) {
+			r, err := run(bot, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Landed {
+				t.Errorf("%v", r)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import "math"
+
+// Strategy decides the next rotate and power command.
+type Strategy interface {
+	Command(l Lander) (rotate, power int)
+}
+
+// selectStrategy picks the strategy matching the episode: a lander starting
+// right above the landing zone without horizontal speed only has to go down,
+// caves need a planner.
+func selectStrategy(s *Surface, l Lander) Strategy {
+	if s.Cave() {
+		return NewPlanner(s)
+	}
+	if s.OverFlat(l.X) && l.HSpeed == 0 {
+		return &Descent{Surface: s, Vertical: NewVerticalController()}
+	}
+	return NewNavigator(s)
+}
+
+// Descent is the episode 1 strategy: a vertical descent above the landing
+// zone.
+type Descent struct {
+	Surface  *Surface
+	Vertical *VerticalController
+}
+
+func (d *Descent) Command(l Lander) (rotate, power int) {
+	return 0, d.Vertical.Power(l, d.Surface)
+}
+
+// Navigator is the episode 2 strategy: it flies to the landing zone,
+// brakes above it and then lands vertically.
+type Navigator struct {
+	Surface  *Surface
+	Vertical *VerticalController
+
+	MaxHSpeed   float64 // horizontal speed allowed before the final descent
+	CruiseSpeed float64 // horizontal speed while travelling to the zone
+	Decel       float64 // deceleration used to plan the braking distance
+	MaxAngle    int     // highest tilt used to accelerate or brake
+	Margin      float64 // distance kept from the edges of the landing zone
+	Clearance   float64 // altitude under which the lander must be upright
+	Lookahead   int     // seconds simulated to detect terrain on the way
+}
+
+func NewNavigator(s *Surface) *Navigator {
+	return &Navigator{
+		Surface:     s,
+		Vertical:    NewVerticalController(),
+		MaxHSpeed:   15,
+		CruiseSpeed: 60,
+		Decel:       1.5,
+		MaxAngle:    30,
+		Margin:      100,
+		Clearance:   150,
+		Lookahead:   15,
+	}
+}
+
+func (n *Navigator) Command(l Lander) (rotate, power int) {
+	s := n.Surface
+	left, right := s.Flat.Left+n.Margin, s.Flat.Right-n.Margin
+	above := l.X >= left && l.X <= right
+	if above && math.Abs(l.HSpeed) <= n.MaxHSpeed {
+		return 0, n.Vertical.Power(l, s)
+	}
+
+	// Aim for a horizontal speed low enough to stop above the zone.
+	target := 0.0
+	if !above {
+		dx := (left+right)/2 - l.X
+		target = math.Min(n.CruiseSpeed, math.Sqrt(2*n.Decel*math.Abs(dx)))
+		if dx < 0 {
+			target = -target
+		}
+	}
+	rotate = n.tilt(l.HSpeed - target)
+	power = maxPower
+	if l.VSpeed > 0 && !above {
+		power = maxPower - 1
+	}
+	if s.Altitude(l.X, l.Y) < n.Clearance || n.collides(l, rotate, power) {
+		return 0, maxPower
+	}
+	return rotate, power
+}
+
+// tilt converts a horizontal speed error into an angle: a positive angle
+// pushes the lander to the left.
+func (n *Navigator) tilt(err float64) int {
+	a := math.Asin(math.Max(-1, math.Min(1, err/maxPower))) * 180 / math.Pi
+	return clamp(int(math.Round(a)), -n.MaxAngle, n.MaxAngle)
+}
+
+// collides reports whether holding the command for the next seconds hits
+// the terrain.
+func (n *Navigator) collides(l Lander, rotate, power int) bool {
+	for i := 0; i < n.Lookahead; i++ {
+		prev := Point{X: l.X, Y: l.Y}
+		l.Step(rotate, power)
+		if n.Surface.Crosses(prev, Point{X: l.X, Y: l.Y}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const minFlatWidth = 1000
+
+type Point struct {
+	X, Y float64
+}
+
+// Surface is the polyline drawn by the surface points, ordered from left
+// to right as sent by the referee.
+type Surface struct {
+	Points []Point
+	Flat   struct {
+		Left, Right, Y float64
+	}
+	flat int // index of the first point of the landing zone
+}
+
+// NewSurface builds a surface from its points and locates the flat landing
+// zone. It returns an error when no flat segment is wide enough.
+func NewSurface(points []Point) (*Surface, error) {
+	s := &Surface{Points: points}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if a.Y != b.Y || b.X-a.X < minFlatWidth {
+			continue
+		}
+		s.Flat.Left = a.X
+		s.Flat.Right = b.X
+		s.Flat.Y = a.Y
+		s.flat = i - 1
+		return s, nil
+	}
+	return s, fmt.Errorf("no flat segment of at least %dm", minFlatWidth)
+}
+
+// OverFlat reports whether x is above the landing zone.
+func (s *Surface) OverFlat(x float64) bool {
+	return x >= s.Flat.Left && x <= s.Flat.Right
+}
+
+// Altitude returns the height of y above the ground at x. Caves make the
+// surface fold back on itself, in that case the closest ground below y is
+// used.
+func (s *Surface) Altitude(x, y float64) float64 {
+	alt := -1.0
+	for i := 1; i < len(s.Points); i++ {
+		a, b := s.Points[i-1], s.Points[i]
+		if a.X == b.X || x < min(a.X, b.X) || x > max(a.X, b.X) {
+			continue
+		}
+		ground := a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)
+		if ground > y {
+			continue
+		}
+		if alt < 0 || y-ground < alt {
+			alt = y - ground
+		}
+	}
+	return alt
+}
+
+// Crosses reports whether the segment going from a to b intersects the
+// terrain.
+func (s *Surface) Crosses(a, b Point) bool {
+	return s.Hit(a, b) >= 0
+}
+
+// Hit returns the index of the first surface point of the terrain segment
+// intersected by the segment going from a to b, or -1.
+func (s *Surface) Hit(a, b Point) int {
+	for i := 1; i < len(s.Points); i++ {
+		if intersect(a, b, s.Points[i-1], s.Points[i]) {
+			return i - 1
+		}
+	}
+	return -1
+}
+
+// Cave reports whether the surface folds back on itself, like in the
+// episode 3 caves.
+func (s *Surface) Cave() bool {
+	for i := 1; i < len(s.Points); i++ {
+		if s.Points[i].X < s.Points[i-1].X {
+			return true
+		}
+	}
+	return false
+}
+
+// Length returns the length of the polyline.
+func (s *Surface) Length() float64 {
+	length := 0.0
+	for i := 1; i < len(s.Points); i++ {
+		length += dist(s.Points[i-1], s.Points[i])
+	}
+	return length
+}
+
+// DistanceToFlat returns the distance to walk along the terrain from the
+// point at x on segment seg to the landing zone.
+func (s *Surface) DistanceToFlat(seg int, x float64) float64 {
+	a, b := s.Points[seg], s.Points[seg+1]
+	p := a
+	if a.X != b.X {
+		p = Point{X: x, Y: a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)}
+	}
+	flat := s.flat
+	if flat == seg {
+		return 0
+	}
+	d := 0.0
+	if seg < flat {
+		d = dist(p, b)
+		for i := seg + 1; i < flat; i++ {
+			d += dist(s.Points[i], s.Points[i+1])
+		}
+	} else {
+		d = dist(a, p)
+		for i := flat + 1; i < seg; i++ {
+			d += dist(s.Points[i], s.Points[i+1])
+		}
+	}
+	return d
+}
+
+func dist(a, b Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+func intersect(p1, p2, q1, q2 Point) bool {
+	d1, d2 := cross(q1, q2, p1), cross(q1, q2, p2)
+	d3, d4 := cross(p1, p2, q1), cross(p1, p2, q2)
+	if d1 == 0 && d2 == 0 {
+		return overlap(p1, p2, q1, q2)
+	}
+	return d1*d2 <= 0 && d3*d4 <= 0
+}
+
+// cross returns the orientation of c relative to the line going from a
+// to b.
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// overlap reports whether two collinear segments share a point.
+func overlap(p1, p2, q1, q2 Point) bool {
+	return max(p1.X, p2.X) >= min(q1.X, q2.X) && max(q1.X, q2.X) >= min(p1.X, p2.X) &&
+		max(p1.Y, p2.Y) >= min(q1.Y, q2.Y) && max(q1.Y, q2.Y) >= min(p1.Y, p2.Y)
+}
@@ -0,0 +1,218 @@
+package main
+
+import "fmt"
+
+// edge is a possible bridge between a node and its right or bottom
+// neighbour.
+type edge struct {
+	A, B    int   // index of the nodes, A is left of or above B
+	Crosses []int // edges which cannot be used together with this one
+}
+
+// bridges holds the bounds of the number of bridges on every edge.
+type bridges struct {
+	lo, hi []int
+}
+
+func (b bridges) clone() bridges {
+	return bridges{
+		lo: append([]int(nil), b.lo...),
+		hi: append([]int(nil), b.hi...),
+	}
+}
+
+// BridgeSolver solves the episode 2 puzzle (Hashiwokakero) with constraint
+// propagation and backtracking.
+type BridgeSolver struct {
+	Nodes []*Node
+	Edges []edge
+	adj   [][]int // edges of every node
+}
+
+func NewBridgeSolver(t *Tree) *BridgeSolver {
+	s := &BridgeSolver{}
+	index := make(map[*Node]int, t.Len())
+	t.Each(func(n *Node) {
+		index[n] = len(s.Nodes)
+		s.Nodes = append(s.Nodes, n)
+	})
+	s.adj = make([][]int, len(s.Nodes))
+	for i, n := range s.Nodes {
+		for _, next := range []*Node{n.Right, n.Bottom} {
+			if next == nil {
+				continue
+			}
+			j := index[next]
+			s.adj[i] = append(s.adj[i], len(s.Edges))
+			s.adj[j] = append(s.adj[j], len(s.Edges))
+			s.Edges = append(s.Edges, edge{A: i, B: j})
+		}
+	}
+	for i := range s.Edges {
+		for j := range s.Edges {
+			if s.cross(i, j) {
+				s.Edges[i].Crosses = append(s.Edges[i].Crosses, j)
+			}
+		}
+	}
+	return s
+}
+
+// cross reports whether edge i is horizontal and crosses vertical edge j,
+// or the other way around.
+func (s *BridgeSolver) cross(i, j int) bool {
+	h1, h2 := s.Nodes[s.Edges[i].A], s.Nodes[s.Edges[i].B]
+	v1, v2 := s.Nodes[s.Edges[j].A], s.Nodes[s.Edges[j].B]
+	if h1.Y != h2.Y {
+		h1, h2, v1, v2 = v1, v2, h1, h2
+	}
+	if h1.Y != h2.Y || v1.X != v2.X {
+		return false
+	}
+	return h1.X < v1.X && v1.X < h2.X && v1.Y < h1.Y && h1.Y < v2.Y
+}
+
+// Solve returns the number of bridges on every edge, or nil when the puzzle
+// has no solution.
+func (s *BridgeSolver) Solve() []int {
+	b := bridges{
+		lo: make([]int, len(s.Edges)),
+		hi: make([]int, len(s.Edges)),
+	}
+	for i := range b.hi {
+		b.hi[i] = 2
+	}
+	if b, ok := s.search(b); ok {
+		return b.lo
+	}
+	return nil
+}
+
+func (s *BridgeSolver) search(b bridges) (bridges, bool) {
+	if !s.propagate(b) || !s.connected(b.hi) {
+		return b, false
+	}
+	// Branch on the undecided edge of the most constrained node.
+	e, best := -1, 0
+	for i := range s.Edges {
+		if b.lo[i] == b.hi[i] {
+			continue
+		}
+		n := s.options(b, s.Edges[i].A) + s.options(b, s.Edges[i].B)
+		if e < 0 || n < best {
+			e, best = i, n
+		}
+	}
+	if e < 0 {
+		return b, s.connected(b.lo)
+	}
+	for v := b.hi[e]; v >= b.lo[e]; v-- {
+		next := b.clone()
+		next.lo[e], next.hi[e] = v, v
+		if next, ok := s.search(next); ok {
+			return next, true
+		}
+	}
+	return b, false
+}
+
+// options returns the number of undecided edges of node n.
+func (s *BridgeSolver) options(b bridges, n int) int {
+	count := 0
+	for _, e := range s.adj[n] {
+		if b.lo[e] != b.hi[e] {
+			count++
+		}
+	}
+	return count
+}
+
+// propagate tightens the bounds until nothing changes. It returns false if
+// a node cannot get its number of bridges.
+func (s *BridgeSolver) propagate(b bridges) bool {
+	for changed := true; changed; {
+		changed = false
+		for n, node := range s.Nodes {
+			lo, hi := 0, 0
+			for _, e := range s.adj[n] {
+				lo += b.lo[e]
+				hi += b.hi[e]
+			}
+			if lo > node.Links || hi < node.Links {
+				return false
+			}
+			for _, e := range s.adj[n] {
+				// Bridges the other edges cannot take must go on this one.
+				if min := node.Links - (hi - b.hi[e]); min > b.lo[e] {
+					b.lo[e] = min
+					changed = true
+				}
+				// Bridges cannot exceed what the node still needs.
+				if max := b.lo[e] + node.Links - lo; max < b.hi[e] {
+					b.hi[e] = max
+					changed = true
+				}
+				if b.lo[e] > b.hi[e] {
+					return false
+				}
+			}
+		}
+		for e, edge := range s.Edges {
+			if b.lo[e] == 0 {
+				continue
+			}
+			for _, c := range edge.Crosses {
+				if b.lo[c] > 0 {
+					return false
+				}
+				if b.hi[c] > 0 {
+					b.hi[c] = 0
+					changed = true
+				}
+			}
+		}
+	}
+	return true
+}
+
+// connected reports whether every node is reachable using the edges with a
+// positive count.
+func (s *BridgeSolver) connected(count []int) bool {
+	if len(s.Nodes) == 0 {
+		return true
+	}
+	seen := make([]bool, len(s.Nodes))
+	stack := []int{0}
+	seen[0] = true
+	reached := 1
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, e := range s.adj[n] {
+			if count[e] == 0 {
+				continue
+			}
+			next := s.Edges[e].A
+			if next == n {
+				next = s.Edges[e].B
+			}
+			if !seen[next] {
+				seen[next] = true
+				reached++
+				stack = append(stack, next)
+			}
+		}
+	}
+	return reached == len(s.Nodes)
+}
+
+// Print writes the bridges as "x1 y1 x2 y2 count" lines.
+func (s *BridgeSolver) Print(count []int) {
+	for e, c := range count {
+		if c == 0 {
+			continue
+		}
+		a, b := s.Nodes[s.Edges[e].A], s.Nodes[s.Edges[e].B]
+		fmt.Println(a.X, a.Y, b.X, b.Y, c)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import "fmt"
 import "os"
 import "bufio"
+import "sort"
 
 //import "strings"
 //import "strconv"
@@ -13,46 +14,132 @@ import "bufio"
 
 type Node struct {
 	X, Y          int
+	Links         int // number of bridges required by episode 2
 	Right, Bottom *Node
 }
 
+func (n *Node) String() string {
+	return fmt.Sprintf("%d %d %s %s", n.X, n.Y, n.Right.coord(), n.Bottom.coord())
+}
+
+// coord returns the coordinates of the node, or "-1 -1" if there is none.
+func (n *Node) coord() string {
+	if n == nil {
+		return "-1 -1"
+	}
+	return fmt.Sprintf("%d %d", n.X, n.Y)
+}
+
+// Tree is a sparse grid: every node is linked to the next node on its row
+// (Right) and to the next node on its column (Bottom). Nodes can be added in
+// any order, rows and columns are kept sorted.
 type Tree struct {
 	root *Node
+	rows map[int]*Node // first node of every row
+	cols map[int]*Node // first node of every column
 	len  int
 }
 
-func (t *Tree) Add(n *Node) {
-	if t.root == nil {
-		t.root = n
-		t.len++
-		return
+// Add inserts n in the grid. It returns false if a node already exists at
+// the same coordinates.
+func (t *Tree) Add(n *Node) bool {
+	if t.rows == nil {
+		t.rows = make(map[int]*Node)
+		t.cols = make(map[int]*Node)
 	}
 
-	e := t.root
-	for e != nil {
-		if e.Y == n.Y || e.Bottom.Y > n.Y {
-			break
-		}
-		e = e.Bottom
+	var left *Node
+	right := t.rows[n.Y]
+	for right != nil && right.X < n.X {
+		left, right = right, right.Right
 	}
-	if e == nil || e.Y != n.Y {
-		e.Bottom = n
-		t.len++
-		return
+	if right != nil && right.X == n.X {
+		return false
+	}
+	var top *Node
+	bottom := t.cols[n.X]
+	for bottom != nil && bottom.Y < n.Y {
+		top, bottom = bottom, bottom.Bottom
 	}
 
-	for e != nil {
-		if e.X == n.X {
-			break
+	n.Right = right
+	if left == nil {
+		t.rows[n.Y] = n
+	} else {
+		left.Right = n
+	}
+	n.Bottom = bottom
+	if top == nil {
+		t.cols[n.X] = n
+	} else {
+		top.Bottom = n
+	}
+	if t.root == nil || n.Y < t.root.Y || (n.Y == t.root.Y && n.X < t.root.X) {
+		t.root = n
+	}
+	t.len++
+	return true
+}
+
+// Get returns the node at the given coordinates, or nil.
+func (t *Tree) Get(x, y int) *Node {
+	for e := t.rows[y]; e != nil && e.X <= x; e = e.Right {
+		if e.X == x {
+			return e
 		}
-		e = e.Right
 	}
+	return nil
 }
 
+// Front returns the top left node.
 func (t *Tree) Front() *Node {
 	return t.root
 }
 
+// Len returns the number of nodes.
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// Row returns the first node of row y, the row is walked through Right.
+func (t *Tree) Row(y int) *Node {
+	return t.rows[y]
+}
+
+// Column returns the first node of column x, the column is walked through
+// Bottom.
+func (t *Tree) Column(x int) *Node {
+	return t.cols[x]
+}
+
+// Rows returns the index of the non empty rows in increasing order.
+func (t *Tree) Rows() []int {
+	return sortedKeys(t.rows)
+}
+
+// Columns returns the index of the non empty columns in increasing order.
+func (t *Tree) Columns() []int {
+	return sortedKeys(t.cols)
+}
+
+// Each calls fn on every node, row by row from the top left node.
+func (t *Tree) Each(fn func(n *Node)) {
+	for _, y := range t.Rows() {
+		for e := t.rows[y]; e != nil; e = e.Right {
+			fn(e)
+		}
+	}
+}
+
+func sortedKeys(m map[int]*Node) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
 func main() {
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Buffer(make([]byte, 1000000), 1000000)
@@ -67,25 +154,36 @@ func main() {
 	scanner.Scan()
 	fmt.Sscan(scanner.Text(), &height)
 
+	var tree Tree
+	bridges := false
 	for i := 0; i < height; i++ {
 		scanner.Scan()
-		line := scanner.Text() // width characters, each either 0 or .
+		line := scanner.Text() // width characters, each either 0 or ., or 1 to 8 for episode 2
 		for j := 0; j < width; j++ {
 			r := line[j]
-			if r != '0' {
+			if r == '.' {
 				continue
 			}
-			// nodes = append(nodes, Node{
-			// 	X:      j,
-			// 	Y:      i,
-			// 	Right:  left,
-			// 	Bottom: top,
-			// })
+			bridges = bridges || r != '0'
+			tree.Add(&Node{X: j, Y: i, Links: int(r - '0')})
 		}
 	}
 
 	// fmt.Fprintln(os.Stderr, "Debug messages...")
 
+	if bridges {
+		// Two coordinates and one integer: a node, one of its neighbors, the number of links connecting them.
+		s := NewBridgeSolver(&tree)
+		count := s.Solve()
+		if count == nil {
+			fmt.Fprintln(os.Stderr, "no solution")
+		}
+		s.Print(count)
+		return
+	}
+
 	// Three coordinates: a node, its right neighbor, its bottom neighbor
-	fmt.Println("0 0 1 0 0 1")
+	tree.Each(func(n *Node) {
+		fmt.Println(n)
+	})
 }
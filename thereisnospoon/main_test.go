@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTreeEmpty(t *testing.T) {
+	var tree Tree
+	if tree.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", tree.Len())
+	}
+	if n := tree.Get(0, 0); n != nil {
+		t.Errorf("Get(0, 0) = %v, want nil", n)
+	}
+	if n := tree.Front(); n != nil {
+		t.Errorf("Front() = %v, want nil", n)
+	}
+	if n := tree.Row(0); n != nil {
+		t.Errorf("Row(0) = %v, want nil", n)
+	}
+	if n := tree.Column(0); n != nil {
+		t.Errorf("Column(0) = %v, want nil", n)
+	}
+	if len(tree.Rows()) != 0 || len(tree.Columns()) != 0 {
+		t.Errorf("Rows() = %v, Columns() = %v, want none", tree.Rows(), tree.Columns())
+	}
+}
+
+func TestTreeSingle(t *testing.T) {
+	var tree Tree
+	if !tree.Add(&Node{X: 2, Y: 3}) {
+		t.Fatal("Add(2, 3) = false")
+	}
+	n := tree.Get(2, 3)
+	if n == nil {
+		t.Fatal("Get(2, 3) = nil")
+	}
+	if got, want := n.String(), "2 3 -1 -1 -1 -1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if tree.Front() != n || tree.Row(3) != n || tree.Column(2) != n {
+		t.Error("Front, Row and Column should all return the single node")
+	}
+	if tree.Get(3, 2) != nil || tree.Get(2, 0) != nil {
+		t.Error("Get should return nil outside of the node")
+	}
+}
+
+// The sparse layout used below, added out of order:
+//
+//	0.2.
+//	....
+//	1..3
+//	.4..
+var sparse = [][2]int{{3, 2}, {1, 3}, {0, 0}, {2, 0}, {0, 2}}
+
+func newSparse(t *testing.T) *Tree {
+	t.Helper()
+	tree := &Tree{}
+	for _, c := range sparse {
+		if !tree.Add(&Node{X: c[0], Y: c[1]}) {
+			t.Fatalf("Add(%d, %d) = false", c[0], c[1])
+		}
+	}
+	return tree
+}
+
+func TestTreeSparse(t *testing.T) {
+	tree := newSparse(t)
+	if tree.Len() != len(sparse) {
+		t.Errorf("Len() = %d, want %d", tree.Len(), len(sparse))
+	}
+	if got, want := tree.Rows(), []int{0, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Rows() = %v, want %v", got, want)
+	}
+	if got, want := tree.Columns(), []int{0, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+	if tree.Row(1) != nil {
+		t.Errorf("Row(1) = %v, want nil", tree.Row(1))
+	}
+	if f := tree.Front(); f == nil || f.X != 0 || f.Y != 0 {
+		t.Errorf("Front() = %v, want 0 0", f)
+	}
+
+	tests := []struct {
+		x, y int
+		want string
+	}{
+		{0, 0, "0 0 2 0 0 2"},
+		{2, 0, "2 0 -1 -1 -1 -1"},
+		{0, 2, "0 2 3 2 -1 -1"},
+		{3, 2, "3 2 -1 -1 -1 -1"},
+		{1, 3, "1 3 -1 -1 -1 -1"},
+	}
+	for _, tt := range tests {
+		n := tree.Get(tt.x, tt.y)
+		if n == nil {
+			t.Errorf("Get(%d, %d) = nil", tt.x, tt.y)
+			continue
+		}
+		if got := n.String(); got != tt.want {
+			t.Errorf("Get(%d, %d) = %q, want %q", tt.x, tt.y, got, tt.want)
+		}
+	}
+	for _, c := range [][2]int{{1, 0}, {1, 1}, {4, 2}, {3, 3}, {-1, 0}} {
+		if n := tree.Get(c[0], c[1]); n != nil {
+			t.Errorf("Get(%d, %d) = %v, want nil", c[0], c[1], n)
+		}
+	}
+
+	var each [][2]int
+	tree.Each(func(n *Node) { each = append(each, [2]int{n.X, n.Y}) })
+	if want := [][2]int{{0, 0}, {2, 0}, {0, 2}, {3, 2}, {1, 3}}; !reflect.DeepEqual(each, want) {
+		t.Errorf("Each visited %v, want %v", each, want)
+	}
+}
+
+func TestTreeDuplicate(t *testing.T) {
+	tree := newSparse(t)
+	orig := tree.Get(0, 2)
+	if tree.Add(&Node{X: 0, Y: 2}) {
+		t.Error("Add of an existing node = true")
+	}
+	if tree.Len() != len(sparse) {
+		t.Errorf("Len() = %d, want %d", tree.Len(), len(sparse))
+	}
+	if tree.Get(0, 2) != orig {
+		t.Error("duplicate replaced the original node")
+	}
+	if got, want := tree.Get(0, 0).Bottom, orig; got != want {
+		t.Errorf("Bottom of 0 0 = %v, want %v", got, want)
+	}
+}